@@ -0,0 +1,7 @@
+data "vkcs_compute_flavor_extra_specs" "specs" {
+  flavor_id = "aee06bce-ea2e-4f8a-9ae5-7e4e6e7d1b3a"
+}
+
+output "cpu_type" {
+  value = data.vkcs_compute_flavor_extra_specs.specs.extra_specs["mcs:cpu_type"]
+}
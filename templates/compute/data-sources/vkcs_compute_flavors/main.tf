@@ -0,0 +1,7 @@
+data "vkcs_compute_flavors" "shared" {
+  shared_with_project = "b5b7ffd4ef0547e5b222f44555dfcdc6"
+}
+
+output "shared_flavor_names" {
+  value = data.vkcs_compute_flavors.shared.flavors[*].name
+}
@@ -0,0 +1,3 @@
+data "vkcs_db_cluster_capabilities" "cluster_capabilities" {
+  cluster_id = "e7da2869-2ae2-4900-99e3-a44fec2b11ac"
+}
@@ -0,0 +1,11 @@
+data "vkcs_networking_floatingip" "db-cluster-with-shards" {
+  port_id = vkcs_db_cluster_with_shards.db-cluster-with-shards.shard[0].instances[0].port_id
+}
+
+resource "vkcs_publicdns_record" "db-cluster-with-shards" {
+  zone_id = vkcs_publicdns_zone.zone.id
+  type    = "A"
+  name    = "clickhouse"
+  ip      = data.vkcs_networking_floatingip.db-cluster-with-shards.address
+  ttl     = 60
+}
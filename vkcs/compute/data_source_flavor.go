@@ -2,13 +2,18 @@ package compute
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"reflect"
-
-	"github.com/hashicorp/go-cty/cty"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/clients"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util/errutil"
@@ -16,8 +21,76 @@ import (
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
 	iflavors "github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/compute/v2/flavors"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/regions/regions"
+)
+
+const computeFlavorLookupTimeout = 15 * time.Second
+
+const computeFlavorExtraSpecsAttempts = 3
+
+var computeFlavorExtraSpecsBackoff = time.Second
+
+const (
+	computeFlavorOnMultipleError = "error"
+	computeFlavorOnMultipleFirst = "first"
+	computeFlavorOnMultipleLast  = "last"
 )
 
+// errComputeFlavorNotListed is returned when the flavor requested by name is
+// not listed at all, e.g. it has just been created and is not listed yet.
+var errComputeFlavorNotListed = errors.New("flavor not listed")
+
+// computeFlavorExtraSpecsCache keeps extra specs of flavors fetched by the data
+// source, so that reads of the same flavor don't call the API again. Entries
+// are keyed by the compute endpoint and the flavor ID, since flavors of
+// different regions may share IDs.
+var computeFlavorExtraSpecsCache = struct {
+	sync.Mutex
+	specs map[string]map[string]string
+}{specs: make(map[string]map[string]string)}
+
+func getComputeFlavorExtraSpecs(ctx context.Context, computeClient *gophercloud.ServiceClient, flavorID string) (map[string]string, error) {
+	key := computeClient.ResourceBaseURL() + flavorID
+
+	computeFlavorExtraSpecsCache.Lock()
+	es, ok := computeFlavorExtraSpecsCache.specs[key]
+	computeFlavorExtraSpecsCache.Unlock()
+	if ok {
+		return es, nil
+	}
+
+	es, err := listComputeFlavorExtraSpecs(ctx, computeClient, flavorID)
+	if err != nil {
+		return nil, err
+	}
+
+	computeFlavorExtraSpecsCache.Lock()
+	computeFlavorExtraSpecsCache.specs[key] = es
+	computeFlavorExtraSpecsCache.Unlock()
+	return es, nil
+}
+
+// listComputeFlavorExtraSpecs retries listing of extra specs a few times when
+// the request is rate limited, which happens when many flavor data sources are
+// read in parallel. Other errors are returned immediately, as well as the last
+// error when ctx is done while waiting for the next attempt.
+func listComputeFlavorExtraSpecs(ctx context.Context, computeClient *gophercloud.ServiceClient, flavorID string) (map[string]string, error) {
+	backoff := computeFlavorExtraSpecsBackoff
+	for attempt := 1; ; attempt++ {
+		es, err := iflavors.ListExtraSpecs(computeClient, flavorID).Extract()
+		if err == nil || !errutil.Is(err, 429) || attempt == computeFlavorExtraSpecsAttempts {
+			return es, err
+		}
+		log.Printf("[DEBUG] Listing extra specs of flavor %s is rate limited, retrying in %s", flavorID, backoff)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
 func DataSourceComputeFlavor() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: dataSourceComputeFlavorRead,
@@ -35,31 +108,49 @@ func DataSourceComputeFlavor() *schema.Resource {
 				Type:          schema.TypeString,
 				Optional:      true,
 				ForceNew:      true,
-				ConflictsWith: []string{"name", "min_ram", "min_disk"},
-				Description:   "The ID of the flavor. Conflicts with the `name`, `min_ram` and `min_disk`",
+				ConflictsWith: []string{"name", "names", "name_contains", "min_ram", "min_disk"},
+				Description:   "The ID of the flavor. Conflicts with the `name`, `names`, `name_contains`, `min_ram` and `min_disk`",
 			},
 
 			"name": {
 				Type:          schema.TypeString,
 				Optional:      true,
 				ForceNew:      true,
-				ConflictsWith: []string{"flavor_id"},
-				Description:   "The name of the flavor. Conflicts with the `flavor_id`.",
+				ConflictsWith: []string{"flavor_id", "names", "name_contains"},
+				Description:   "The name of the flavor. Conflicts with the `flavor_id`, `names` and `name_contains`.",
+			},
+
+			"names": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				ForceNew:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"flavor_id", "name", "name_contains"},
+				Description:   "The list of candidate flavor names in order of preference. The first name matching an existing flavor is used. Conflicts with the `flavor_id`, `name` and `name_contains`.",
+			},
+
+			"name_contains": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"flavor_id", "name", "names"},
+				Description:   "The substring of the flavor name. Conflicts with the `flavor_id`, `name` and `names`.",
 			},
 
 			"min_ram": {
 				Type:          schema.TypeInt,
 				Optional:      true,
 				ForceNew:      true,
-				ConflictsWith: []string{"flavor_id"},
-				Description:   "The minimum amount of RAM (in megabytes). Conflicts with the `flavor_id`.",
+				ConflictsWith: []string{"flavor_id", "ram"},
+				Description:   "The minimum amount of RAM (in megabytes). If `name` is set, the named flavor is required to have at least this amount of RAM. Conflicts with the `flavor_id` and `ram`.",
 			},
 
 			"ram": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				ForceNew:    true,
-				Description: "The exact amount of RAM (in megabytes). Don't set ram, when min_ram is set.",
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"min_ram"},
+				Description:   "The exact amount of RAM (in megabytes). Conflicts with the `min_ram`.",
 			},
 
 			"vcpus": {
@@ -73,22 +164,23 @@ func DataSourceComputeFlavor() *schema.Resource {
 				Type:          schema.TypeInt,
 				Optional:      true,
 				ForceNew:      true,
-				ConflictsWith: []string{"flavor_id"},
-				Description:   "The minimum amount of disk (in gigabytes). Conflicts with the `flavor_id`.",
+				ConflictsWith: []string{"flavor_id", "disk"},
+				Description:   "The minimum amount of disk (in gigabytes). Conflicts with the `flavor_id` and `disk`.",
 			},
 
 			"disk": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				ForceNew:    true,
-				Description: "The exact amount of disk (in gigabytes). Don't set disk, when min_disk is set.",
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"min_disk"},
+				Description:   "The exact amount of disk (in gigabytes). Conflicts with the `min_disk`.",
 			},
 
 			"swap": {
 				Type:        schema.TypeInt,
 				Optional:    true,
 				ForceNew:    true,
-				Description: "The amount of swap (in gigabytes).",
+				Description: "The exact amount of swap (in gigabytes). Swap has no minimum counterpart and is always matched exactly.",
 			},
 
 			"rx_tx_factor": {
@@ -105,11 +197,54 @@ func DataSourceComputeFlavor() *schema.Resource {
 				Description: "The flavor visibility.",
 			},
 
+			"on_multiple": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  computeFlavorOnMultipleError,
+				ValidateFunc: validation.StringInSlice([]string{
+					computeFlavorOnMultipleError, computeFlavorOnMultipleFirst, computeFlavorOnMultipleLast,
+				}, false),
+				Description: "Behavior when the query returns more than one flavor and neither `min_ram` nor `min_disk` is set. " +
+					"Must be one of `error`, `first` or `last`. `first` and `last` choose a flavor from the found ones sorted by ID " +
+					"in ascending lexicographical order. Default is `error`.",
+			},
+
 			"extra_specs": {
 				Type:        schema.TypeMap,
 				Optional:    true,
 				Computed:    true,
-				Description: "Key/Value pairs of metadata for the flavor. Be careful when using it, there is no validation applied to this field. When searching for a suitable flavor, it checks all required extra specs in a flavor metadata. See https://cloud.vk.com/docs/base/iaas/concepts/vm-concept",
+				Description: "Key/Value pairs of metadata for the flavor. Be careful when using it, there is no validation applied to this field unless `validate_extra_specs` is set. When searching for a suitable flavor, it checks all required extra specs in a flavor metadata. See https://cloud.vk.com/docs/base/iaas/concepts/vm-concept",
+			},
+
+			"validate_extra_specs": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Warn about keys of `extra_specs` that are not present in any of the candidate flavors. Helps to catch misspelled keys.",
+			},
+
+			"strict_extra_specs": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Fail when keys of `extra_specs` are not present in any of the candidate flavors instead of returning no results. The error names the missing keys.",
+			},
+
+			"generation_extra_spec": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The extra spec holding the hardware generation of flavors, e.g. `mcs:cpu_generation`. If set, only flavors of the newest generation among the found ones are considered. Flavors without the extra spec are considered only if none of the found flavors has it.",
+			},
+
+			"generation": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The generation of the found flavor. Set only if `generation_extra_spec` is set.",
+			},
+
+			"description": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The description of the found flavor.",
 			},
 
 			"id": {
@@ -143,6 +278,14 @@ type RequiredFlavor struct {
 	Name    string `json:"name"`
 	HasName bool   `json:"has_name"`
 
+	// Names are candidate names of the flavor in order of preference.
+	Names    []string `json:"names"`
+	HasNames bool     `json:"has_names"`
+
+	// NameContains is the substring of the flavor name.
+	NameContains    string `json:"name_contains"`
+	HasNameContains bool   `json:"has_name_contains"`
+
 	// RxTxFactor describes bandwidth alterations of the flavor.
 	RxTxFactor    float64 `json:"rxtx_factor"`
 	HasRxTxFactor bool    `json:"has_rxtx_factor"`
@@ -160,10 +303,18 @@ type RequiredFlavor struct {
 	HasExtraSpecs bool                   `json:"has_extra_specs"`
 
 	AccessType flavors.AccessType `json:"access_type"`
+
+	// ValidateExtraSpecs enables search of extra specs unknown to candidate flavors.
+	ValidateExtraSpecs bool `json:"validate_extra_specs"`
+
+	// StrictExtraSpecs makes extra specs unknown to candidate flavors an error.
+	StrictExtraSpecs bool `json:"strict_extra_specs"`
 }
 
 func NewRequiredFlavorFromResourceData(d *schema.ResourceData) *RequiredFlavor {
 	name, hasName := d.GetOk("name")
+	rawNames, hasNames := d.GetOk("names")
+	nameContains, hasNameContains := d.GetOk("name_contains")
 	ram, hasRAM := d.GetOk("ram")
 	VCPUs, hasVCPUs := d.GetOk("vcpus")
 	disk, hasDisk := d.GetOk("disk")
@@ -173,6 +324,13 @@ func NewRequiredFlavorFromResourceData(d *schema.ResourceData) *RequiredFlavor {
 	swap, hasSwap := d.GetOk("swap")
 	extraSpecs, hasExtraSpecs := d.GetOk("extra_specs")
 
+	var names []string
+	if hasNames {
+		for _, n := range rawNames.([]interface{}) {
+			names = append(names, n.(string))
+		}
+	}
+
 	if hasRAM {
 		minRAM = ram
 	}
@@ -181,64 +339,99 @@ func NewRequiredFlavorFromResourceData(d *schema.ResourceData) *RequiredFlavor {
 	}
 
 	accessType := flavors.AllAccess
-	if v, ok := d.GetOk("is_public"); ok {
-		if v, ok := v.(bool); ok {
-			if v {
-				accessType = flavors.PublicAccess
-			} else {
-				accessType = flavors.PrivateAccess
-			}
+	if isPublic, ok := getComputeFlavorRequiredIsPublic(d); ok {
+		if isPublic {
+			accessType = flavors.PublicAccess
+		} else {
+			accessType = flavors.PrivateAccess
 		}
 	}
 
 	return &RequiredFlavor{
-		Disk:          disk.(int),
-		HasDisk:       hasDisk,
-		MinDisk:       minDisk.(int),
-		HasMinDisk:    hasMinDisk,
-		RAM:           ram.(int),
-		HasRAM:        hasRAM,
-		MinRAM:        minRAM.(int),
-		HasMinRAM:     hasMinRAM,
-		Name:          name.(string),
-		HasName:       hasName,
-		RxTxFactor:    rxTxFactor.(float64),
-		HasRxTxFactor: hasRxTxFactor,
-		Swap:          swap.(int),
-		HasSwap:       hasSwap,
-		VCPUs:         VCPUs.(int),
-		HasVCPUs:      hasVCPUs,
-		ExtraSpecs:    extraSpecs.(map[string]interface{}),
-		HasExtraSpecs: hasExtraSpecs,
-		AccessType:    accessType,
+		Disk:            disk.(int),
+		HasDisk:         hasDisk,
+		MinDisk:         minDisk.(int),
+		HasMinDisk:      hasMinDisk,
+		RAM:             ram.(int),
+		HasRAM:          hasRAM,
+		MinRAM:          minRAM.(int),
+		HasMinRAM:       hasMinRAM,
+		Name:            name.(string),
+		HasName:         hasName,
+		Names:           names,
+		HasNames:        hasNames,
+		NameContains:    nameContains.(string),
+		HasNameContains: hasNameContains,
+		RxTxFactor:      rxTxFactor.(float64),
+		HasRxTxFactor:   hasRxTxFactor,
+		Swap:            swap.(int),
+		HasSwap:         hasSwap,
+		VCPUs:           VCPUs.(int),
+		HasVCPUs:        hasVCPUs,
+		ExtraSpecs:      extraSpecs.(map[string]interface{}),
+		HasExtraSpecs:   hasExtraSpecs,
+		AccessType:      accessType,
+
+		ValidateExtraSpecs: d.Get("validate_extra_specs").(bool),
+		StrictExtraSpecs:   d.Get("strict_extra_specs").(bool),
 	}
 }
 
+// getComputeFlavorRequiredIsPublic returns is_public from the configuration.
+// Raw configuration is used, since false can't be told apart from unset by
+// GetOk.
+func getComputeFlavorRequiredIsPublic(d *schema.ResourceData) (bool, bool) {
+	v := d.GetRawConfig().GetAttr("is_public")
+	if !v.IsKnown() || v.IsNull() {
+		return false, false
+	}
+	return v.True(), true
+}
+
 // FlavorExt needs for extract FlavorExtExtraSpecs from flavors.FlavorPage
 type FlavorExt struct {
 	flavors.Flavor
 	iflavors.FlavorExtExtraSpecs
+	iflavors.FlavorExtDescription
 }
 
 // dataSourceComputeFlavorRead performs the flavor lookup.
 func dataSourceComputeFlavorRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	computeClient, err := config.ComputeV2Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	identityClient, err := config.IdentityV3Client(region)
+	if err != nil {
+		return diag.Errorf("Error creating VKCS identity client: %s", err)
+	}
+	if err := checkComputeFlavorRegion(identityClient, region); err != nil {
+		return diag.FromErr(err)
+	}
+
+	computeClient, err := config.ComputeV2Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS compute client: %s", err)
 	}
+	computeClient.Microversion = computeFlavorMicroVersion
 
 	// choose only one by flavor_id
 	if v := d.Get("flavor_id").(string); v != "" {
-		flavor, err := iflavors.Get(computeClient, v).Extract()
+		var flavor FlavorExt
+		err := iflavors.ExtractFlavorInto(iflavors.Get(computeClient, v), &flavor)
 		if err != nil {
 			if errutil.IsNotFound(err) {
-				return diag.Errorf("No Flavor found")
+				return diag.Errorf("No Flavor found in region %s", region)
 			}
 			return diag.Errorf("Unable to retrieve VKCS %s flavor: %s", v, err)
 		}
 
-		return diag.FromErr(dataSourceComputeFlavorAttributes(d, computeClient, &FlavorExt{Flavor: *flavor}))
+		if isPublic, ok := getComputeFlavorRequiredIsPublic(d); ok && flavor.IsPublic != isPublic {
+			return diag.Errorf("Flavor %s has is_public = %t, but is_public = %t is requested", v, flavor.IsPublic, isPublic)
+		}
+
+		return diag.FromErr(dataSourceComputeFlavorAttributes(ctx, d, computeClient, &flavor))
 	}
 
 	requiredFlavor := NewRequiredFlavorFromResourceData(d)
@@ -248,17 +441,141 @@ func dataSourceComputeFlavorRead(ctx context.Context, d *schema.ResourceData, me
 		AccessType: requiredFlavor.AccessType,
 	}
 
+	// min_ram is an assertion on the named flavor, so it is checked after the
+	// flavor is found to report a flavor with too little RAM.
+	if requiredFlavor.HasName && requiredFlavor.HasMinRAM {
+		listOpts.MinRAM = 0
+	}
+
 	log.Printf("[DEBUG] vkcs_compute_flavor ListOpts: %#v", listOpts)
 
+	var allFlavors []FlavorExt
+	var unknownExtraSpecs []string
+	err = retry.RetryContext(ctx, computeFlavorLookupTimeout, func() *retry.RetryError {
+		foundFlavors, unknownSpecs, err := findComputeFlavors(computeClient, listOpts, requiredFlavor)
+		// Flavor that has just been created may not be listed yet
+		if errors.Is(err, errComputeFlavorNotListed) || errutil.Any(err, []int{429, 500, 502, 503, 504}) {
+			return retry.RetryableError(err)
+		}
+		if err != nil {
+			return retry.NonRetryableError(err)
+		}
+		unknownExtraSpecs = unknownSpecs
+		allFlavors = foundFlavors
+		return nil
+	})
+	if err != nil && !errors.Is(err, errComputeFlavorNotListed) {
+		return diag.FromErr(err)
+	}
+
+	if requiredFlavor.StrictExtraSpecs && len(unknownExtraSpecs) > 0 {
+		return diag.Errorf("None of the candidate flavors in region %s has extra specs %s", region, strings.Join(unknownExtraSpecs, ", "))
+	}
+
+	var diags diag.Diagnostics
+	for _, spec := range unknownExtraSpecs {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Unknown extra spec %q", spec),
+			Detail: fmt.Sprintf("None of the candidate flavors has extra spec %q, so no flavor can match it. "+
+				"Please check the key for typos.", spec),
+		})
+	}
+
+	if len(allFlavors) < 1 {
+		return append(diags, diag.Errorf("Your query returned no results in region %s. "+
+			"Please change your search criteria or region and try again.", region)...)
+	}
+
+	var generation string
+	if specKey := d.Get("generation_extra_spec").(string); specKey != "" {
+		allFlavors, generation = newestComputeFlavorGeneration(allFlavors, specKey)
+		log.Printf("[DEBUG] Newest generation of found flavors is %q", generation)
+	}
+	d.Set("generation", generation)
+
+	// if we find many flavors and the user sets the min_ram or min_disk values
+	// we give him the flavor with the minimum amount of RAM from the found flavors,
+	// preferring less disk and then less VCPUs. Exact filters like vcpus are
+	// already applied by findComputeFlavors, so only matching flavors compete.
+	if len(allFlavors) > 1 && (requiredFlavor.HasMinRAM || requiredFlavor.HasMinDisk) {
+		resIdx := smallestComputeFlavorIndex(allFlavors)
+		return append(diags, diag.FromErr(dataSourceComputeFlavorAttributes(ctx, d, computeClient, &allFlavors[resIdx]))...)
+	}
+
+	if len(allFlavors) > 1 {
+		log.Printf("[DEBUG] Multiple results found: %#v", allFlavors)
+		sort.Slice(allFlavors, func(i, j int) bool {
+			return allFlavors[i].ID < allFlavors[j].ID
+		})
+		switch d.Get("on_multiple").(string) {
+		case computeFlavorOnMultipleFirst:
+			return append(diags, diag.FromErr(dataSourceComputeFlavorAttributes(ctx, d, computeClient, &allFlavors[0]))...)
+		case computeFlavorOnMultipleLast:
+			return append(diags, diag.FromErr(dataSourceComputeFlavorAttributes(ctx, d, computeClient, &allFlavors[len(allFlavors)-1]))...)
+		}
+		return append(diags, diag.Errorf("Your query returned more than one result. Please try a more specific search criteria")...)
+	}
+
+	return append(diags, diag.FromErr(dataSourceComputeFlavorAttributes(ctx, d, computeClient, &allFlavors[0]))...)
+}
+
+// computeFlavorRegionChecks keeps regions known to exist, so that the region
+// is checked once per provider configuration rather than on every read.
+// Entries are keyed by the identity endpoint and the region. Failed checks are
+// not kept, so that they are retried on the next read.
+var computeFlavorRegionChecks = struct {
+	sync.Mutex
+	exists map[string]bool
+}{exists: make(map[string]bool)}
+
+// checkComputeFlavorRegion makes sure that the region exists, so that searching
+// in a wrong region is reported before querying flavors.
+func checkComputeFlavorRegion(identityClient *gophercloud.ServiceClient, region string) error {
+	key := identityClient.ResourceBaseURL() + region
+	computeFlavorRegionChecks.Lock()
+	defer computeFlavorRegionChecks.Unlock()
+	if computeFlavorRegionChecks.exists[key] {
+		return nil
+	}
+
+	_, err := regions.Get(identityClient, region).Extract()
+	if err != nil {
+		if errutil.IsNotFound(err) {
+			return fmt.Errorf("region %s does not exist", region)
+		}
+		log.Printf("[WARN] Unable to check region %s for vkcs_compute_flavor: %s", region, err)
+		return nil
+	}
+	computeFlavorRegionChecks.exists[key] = true
+	return nil
+}
+
+// findComputeFlavors lists flavors and filters them by the required attributes.
+// If validation of extra specs is required, it also returns required extra specs
+// that none of the listed flavors has.
+func findComputeFlavors(computeClient *gophercloud.ServiceClient, listOpts flavors.ListOpts, requiredFlavor *RequiredFlavor) ([]FlavorExt, []string, error) {
 	allPages, err := flavors.ListDetail(computeClient, listOpts).AllPages()
 	if err != nil {
-		return diag.Errorf("Unable to query VKCS flavors: %s", err)
+		return nil, nil, fmt.Errorf("unable to query VKCS flavors: %w", err)
 	}
 
 	var allFlavors []FlavorExt
 	err = iflavors.ExtractFlavorsInto(allPages, &allFlavors)
 	if err != nil {
-		return diag.Errorf("Unable to retrieve VKCS flavors: %s", err)
+		return nil, nil, fmt.Errorf("unable to retrieve VKCS flavors: %s", err)
+	}
+
+	// Only a listing without server-side filters tells that the flavor is
+	// not listed at all rather than filtered out.
+	unfiltered := listOpts.MinDisk == 0 && listOpts.MinRAM == 0 && (listOpts.AccessType == "" || listOpts.AccessType == flavors.AllAccess)
+	if requiredFlavor.HasName && unfiltered && !computeFlavorListed(allFlavors, requiredFlavor.Name) {
+		return nil, nil, fmt.Errorf("%w: %s", errComputeFlavorNotListed, requiredFlavor.Name)
+	}
+
+	var unknownExtraSpecs []string
+	if requiredFlavor.HasExtraSpecs && (requiredFlavor.ValidateExtraSpecs || requiredFlavor.StrictExtraSpecs) {
+		unknownExtraSpecs = unknownComputeFlavorExtraSpecs(allFlavors, requiredFlavor.ExtraSpecs)
 	}
 
 	// Loop through all flavors to find a more specific one.
@@ -269,6 +586,8 @@ func dataSourceComputeFlavorRead(ctx context.Context, d *schema.ResourceData, me
 			switch {
 			case requiredFlavor.HasName && flavor.Name != requiredFlavor.Name:
 				continue
+			case requiredFlavor.HasNameContains && !strings.Contains(flavor.Name, requiredFlavor.NameContains):
+				continue
 			case requiredFlavor.HasRAM && flavor.RAM != requiredFlavor.RAM:
 				continue
 			case requiredFlavor.HasVCPUs && flavor.VCPUs != requiredFlavor.VCPUs:
@@ -289,7 +608,7 @@ func dataSourceComputeFlavorRead(ctx context.Context, d *schema.ResourceData, me
 
 			for spec, reqVal := range requiredFlavor.ExtraSpecs {
 				val, ok := flavor.ExtraSpecs[spec]
-				if !ok || !reflect.DeepEqual(val, reqVal) {
+				if !ok || normalizeComputeFlavorExtraSpec(val) != normalizeComputeFlavorExtraSpec(reqVal) {
 					continue FlavorsLoop
 				}
 			}
@@ -300,56 +619,53 @@ func dataSourceComputeFlavorRead(ctx context.Context, d *schema.ResourceData, me
 		allFlavors = filteredFlavors
 	}
 
-	diags := diag.Diagnostics{}
-	if requiredFlavor.HasMinDisk && requiredFlavor.HasDisk {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Warning,
-			Summary:  "Don't set min_disk and disk together, min_disk will be overwritten by disk",
-			AttributePath: cty.Path{
-				cty.GetAttrStep{Name: "min_disk"},
-			},
-		})
-	}
-	if requiredFlavor.HasMinRAM && requiredFlavor.HasRAM {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Warning,
-			Summary:  "Don't set min_ram and ram together, min_ram will be overwritten by ram",
-			AttributePath: cty.Path{
-				cty.GetAttrStep{Name: "min_ram"},
-			},
-		})
-	}
-
-	if len(allFlavors) < 1 {
-		return append(diags, diag.Errorf("Your query returned no results. "+
-			"Please change your search criteria and try again.")...)
+	if requiredFlavor.HasNames {
+		allFlavors = preferredComputeFlavorsByName(allFlavors, requiredFlavor.Names)
 	}
 
-	// if we find many flavors and the user sets the min_ram or min_disk values
-	// we give him the flavor with the minimum amount of RAM from the found flavors
-	if len(allFlavors) > 1 && (requiredFlavor.HasMinRAM || requiredFlavor.HasMinDisk) {
-		resIdx := 0
-		for idx, flavor := range allFlavors {
-			if flavor.RAM == allFlavors[resIdx].RAM && flavor.Disk < allFlavors[resIdx].Disk {
-				resIdx = idx
-			} else if flavor.RAM < allFlavors[resIdx].RAM {
-				resIdx = idx
+	if requiredFlavor.HasName && requiredFlavor.HasMinRAM {
+		for _, flavor := range allFlavors {
+			if flavor.RAM < requiredFlavor.MinRAM {
+				return nil, nil, fmt.Errorf("flavor %s has %d MB of RAM, but min_ram = %d is requested", flavor.Name, flavor.RAM, requiredFlavor.MinRAM)
 			}
 		}
+	}
 
-		return append(diags, diag.FromErr(dataSourceComputeFlavorAttributes(d, computeClient, &allFlavors[resIdx]))...)
+	return allFlavors, unknownExtraSpecs, nil
+}
+
+// computeFlavorListed reports whether a flavor with the name is listed.
+func computeFlavorListed(allFlavors []FlavorExt, name string) bool {
+	for _, flavor := range allFlavors {
+		if flavor.Name == name {
+			return true
+		}
 	}
+	return false
+}
 
-	if len(allFlavors) > 1 {
-		log.Printf("[DEBUG] Multiple results found: %#v", allFlavors)
-		return diag.Errorf("Your query returned more than one result. Please try a more specific search criteria")
+// unknownComputeFlavorExtraSpecs returns sorted keys of required extra specs
+// that are not present in any of the flavors.
+func unknownComputeFlavorExtraSpecs(allFlavors []FlavorExt, extraSpecs map[string]interface{}) []string {
+	knownSpecs := make(map[string]struct{})
+	for _, flavor := range allFlavors {
+		for spec := range flavor.ExtraSpecs {
+			knownSpecs[spec] = struct{}{}
+		}
 	}
 
-	return append(diags, diag.FromErr(dataSourceComputeFlavorAttributes(d, computeClient, &allFlavors[0]))...)
+	var unknownSpecs []string
+	for spec := range extraSpecs {
+		if _, ok := knownSpecs[spec]; !ok {
+			unknownSpecs = append(unknownSpecs, spec)
+		}
+	}
+	sort.Strings(unknownSpecs)
+	return unknownSpecs
 }
 
 // dataSourceComputeFlavorAttributes populates the fields of a Flavor resource.
-func dataSourceComputeFlavorAttributes(d *schema.ResourceData, computeClient *gophercloud.ServiceClient, flavor *FlavorExt) error {
+func dataSourceComputeFlavorAttributes(ctx context.Context, d *schema.ResourceData, computeClient *gophercloud.ServiceClient, flavor *FlavorExt) error {
 	log.Printf("[DEBUG] Retrieved vkcs_compute_flavor %s: %#v", flavor.ID, flavor)
 
 	d.SetId(flavor.ID)
@@ -361,13 +677,14 @@ func dataSourceComputeFlavorAttributes(d *schema.ResourceData, computeClient *go
 	d.Set("swap", flavor.Swap)
 	d.Set("vcpus", flavor.VCPUs)
 	d.Set("is_public", flavor.IsPublic)
+	d.Set("description", flavor.Description)
 
 	if flavor.ExtraSpecs != nil {
 		if err := d.Set("extra_specs", flavor.ExtraSpecs); err != nil {
 			log.Printf("[WARN] Unable to set extra_specs for vkcs_compute_flavor %s: %s", d.Id(), err)
 		}
 	} else {
-		es, err := iflavors.ListExtraSpecs(computeClient, d.Id()).Extract()
+		es, err := getComputeFlavorExtraSpecs(ctx, computeClient, d.Id())
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,68 @@
+package compute
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/clients"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
+)
+
+func DataSourceComputeFlavorExtraSpecs() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceComputeFlavorExtraSpecsRead,
+
+		Schema: map[string]*schema.Schema{
+			"region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The region in which to obtain the Compute client. If omitted, the `region` argument of the provider is used.",
+			},
+
+			"flavor_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The ID of the flavor.",
+			},
+
+			// computed-only
+			"extra_specs": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Key/Value pairs of metadata for the flavor.",
+			},
+		},
+		Description: "Use this data source to get extra specs of a known VKCS flavor without looking up the flavor itself.",
+	}
+}
+
+func dataSourceComputeFlavorExtraSpecsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(clients.Config)
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	computeClient, err := config.ComputeV2Client(region)
+	if err != nil {
+		return diag.Errorf("Error creating VKCS compute client: %s", err)
+	}
+
+	flavorID := d.Get("flavor_id").(string)
+	es, err := getComputeFlavorExtraSpecs(ctx, computeClient, flavorID)
+	if err != nil {
+		return diag.Errorf("Error retrieving extra specs of vkcs_compute_flavor %s: %s", flavorID, err)
+	}
+
+	d.SetId(flavorID)
+
+	log.Printf("[DEBUG] Retrieved extra specs of vkcs_compute_flavor %s: %#v", d.Id(), es)
+
+	d.Set("extra_specs", es)
+	d.Set("region", region)
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package compute_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/acctest"
+)
+
+func TestAccComputeFlavorExtraSpecsDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccComputeFlavorExtraSpecsDataSourceBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.vkcs_compute_flavor_extra_specs.specs", "id",
+						"data.vkcs_compute_flavor.flavor_1", "id"),
+					resource.TestCheckResourceAttr(
+						"data.vkcs_compute_flavor_extra_specs.specs", "extra_specs.%", "4"),
+					resource.TestCheckResourceAttr(
+						"data.vkcs_compute_flavor_extra_specs.specs", "extra_specs.mcs:cpu_type", "standard"),
+				),
+			},
+		},
+	})
+}
+
+const testAccComputeFlavorExtraSpecsDataSourceBasic = `
+data "vkcs_compute_flavor" "flavor_1" {
+  name = "Basic-1-2-20"
+}
+
+data "vkcs_compute_flavor_extra_specs" "specs" {
+  flavor_id = data.vkcs_compute_flavor.flavor_1.id
+}
+`
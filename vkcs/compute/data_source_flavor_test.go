@@ -2,6 +2,7 @@ package compute_test
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
@@ -59,6 +60,22 @@ func TestAccComputeFlavorDataSource_testQueries(t *testing.T) {
 						"data.vkcs_compute_flavor.flavor_1", "is_public", "true"),
 				),
 			},
+			{
+				Config: testAccComputeFlavorDataSourceQueryNameContains,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeFlavorDataSourceID("data.vkcs_compute_flavor.flavor_1"),
+					resource.TestCheckResourceAttr(
+						"data.vkcs_compute_flavor.flavor_1", "name", "Basic-1-2-20"),
+				),
+			},
+			{
+				Config: testAccComputeFlavorDataSourceQueryOnMultipleFirst,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeFlavorDataSourceID("data.vkcs_compute_flavor.flavor_1"),
+					resource.TestCheckResourceAttr(
+						"data.vkcs_compute_flavor.flavor_1", "vcpus", "1"),
+				),
+			},
 			{
 				Config: testAccComputeFlavorDataSourceQueryMinDiskWithName,
 				Check: resource.ComposeTestCheckFunc(
@@ -216,6 +233,36 @@ func TestAccComputeFlavorDataSource_extraSpecs(t *testing.T) {
 	})
 }
 
+func TestAccComputeFlavorDataSource_conflictingFilters(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccComputeFlavorDataSourceQueryDiskAndMinDisk,
+				ExpectError: regexp.MustCompile(`"disk": conflicts with min_disk`),
+			},
+			{
+				Config:      testAccComputeFlavorDataSourceQueryRAMAndMinRAM,
+				ExpectError: regexp.MustCompile(`"ram": conflicts with min_ram`),
+			},
+		},
+	})
+}
+
+func TestAccComputeFlavorDataSource_flavorIDIsPublicMismatch(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccComputeFlavorDataSourceFlavorIDPrivate,
+				ExpectError: regexp.MustCompile(`has is_public = true, but is_public = false is requested`),
+			},
+		},
+	})
+}
+
 func testAccCheckComputeFlavorDataSourceID(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -243,6 +290,19 @@ data "vkcs_compute_flavor" "flavor_1" {
 }
 `
 
+const testAccComputeFlavorDataSourceQueryNameContains = `
+data "vkcs_compute_flavor" "flavor_1" {
+  name_contains = "asic-1-2-2"
+}
+`
+
+const testAccComputeFlavorDataSourceQueryOnMultipleFirst = `
+data "vkcs_compute_flavor" "flavor_1" {
+  vcpus       = 1
+  on_multiple = "first"
+}
+`
+
 const testAccComputeFlavorDataSourceQueryMinDiskWithName = `
 data "vkcs_compute_flavor" "flavor_1" {
   name = "Basic-1-2-20"
@@ -295,3 +355,29 @@ data "vkcs_compute_flavor" "flavor_1" {
 	name = "Basic-1-2-20"
   }
 `
+
+const testAccComputeFlavorDataSourceQueryDiskAndMinDisk = `
+data "vkcs_compute_flavor" "flavor_1" {
+  disk     = 20
+  min_disk = 10
+  swap     = 0
+}
+`
+
+const testAccComputeFlavorDataSourceQueryRAMAndMinRAM = `
+data "vkcs_compute_flavor" "flavor_1" {
+  ram     = 2048
+  min_ram = 1024
+}
+`
+
+const testAccComputeFlavorDataSourceFlavorIDPrivate = `
+data "vkcs_compute_flavor" "public" {
+  name = "Basic-1-2-20"
+}
+
+data "vkcs_compute_flavor" "flavor_1" {
+  flavor_id = data.vkcs_compute_flavor.public.id
+  is_public = false
+}
+`
@@ -0,0 +1,168 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/clients"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	"github.com/gophercloud/utils/terraform/hashcode"
+	iflavors "github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/compute/v2/flavors"
+)
+
+func DataSourceComputeFlavors() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceComputeFlavorsRead,
+
+		Schema: map[string]*schema.Schema{
+			"region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The region in which to obtain the Compute client. If omitted, the `region` argument of the provider is used.",
+			},
+
+			"shared_with_project": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The ID of the project private flavors are shared with. If set, only flavors whose access list contains the project are returned.",
+			},
+
+			// computed-only
+			"flavors": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The ID of the flavor.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the flavor.",
+						},
+						"is_public": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "The flavor visibility.",
+						},
+						"description": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The description of the flavor.",
+						},
+					},
+				},
+				Description: "The flavors, ordered by ID.",
+			},
+		},
+		Description: "Use this data source to get a list of VKCS flavors, e.g. flavors shared with a project.",
+	}
+}
+
+func dataSourceComputeFlavorsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(clients.Config)
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	computeClient, err := config.ComputeV2Client(region)
+	if err != nil {
+		return diag.Errorf("Error creating VKCS compute client: %s", err)
+	}
+	computeClient.Microversion = computeFlavorMicroVersion
+
+	allFlavors, err := listComputeFlavors(computeClient, d.Get("shared_with_project").(string))
+	if err != nil {
+		return diag.Errorf("Error retrieving vkcs_compute_flavors: %s", err)
+	}
+
+	log.Printf("[DEBUG] Retrieved vkcs_compute_flavors: %#v", allFlavors)
+
+	ids := make([]string, 0, len(allFlavors))
+	flattenedFlavors := make([]map[string]interface{}, 0, len(allFlavors))
+	for _, flavor := range allFlavors {
+		ids = append(ids, flavor.ID)
+		flattenedFlavors = append(flattenedFlavors, map[string]interface{}{
+			"id":          flavor.ID,
+			"name":        flavor.Name,
+			"is_public":   flavor.IsPublic,
+			"description": flavor.Description,
+		})
+	}
+
+	d.SetId(hashcode.Strings(ids))
+	d.Set("flavors", flattenedFlavors)
+	d.Set("region", region)
+
+	return nil
+}
+
+// listComputeFlavors lists public and private flavors ordered by ID. If
+// projectID is set, only flavors shared with the project are returned.
+func listComputeFlavors(computeClient *gophercloud.ServiceClient, projectID string) ([]FlavorExt, error) {
+	allPages, err := flavors.ListDetail(computeClient, flavors.ListOpts{AccessType: flavors.AllAccess}).AllPages()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query VKCS flavors: %s", err)
+	}
+
+	var allFlavors []FlavorExt
+	err = iflavors.ExtractFlavorsInto(allPages, &allFlavors)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve VKCS flavors: %s", err)
+	}
+
+	if projectID != "" {
+		var sharedFlavors []FlavorExt
+		for i := range allFlavors {
+			shared, err := computeFlavorSharedWithProject(computeClient, &allFlavors[i], projectID)
+			if err != nil {
+				return nil, err
+			}
+			if shared {
+				sharedFlavors = append(sharedFlavors, allFlavors[i])
+			}
+		}
+		allFlavors = sharedFlavors
+	}
+
+	sort.Slice(allFlavors, func(i, j int) bool {
+		return allFlavors[i].ID < allFlavors[j].ID
+	})
+	return allFlavors, nil
+}
+
+// computeFlavorSharedWithProject checks whether the private flavor is shared
+// with the project. Public flavors have no access list.
+func computeFlavorSharedWithProject(computeClient *gophercloud.ServiceClient, flavor *FlavorExt, projectID string) (bool, error) {
+	if flavor.IsPublic {
+		return false, nil
+	}
+
+	allPages, err := flavors.ListAccesses(computeClient, flavor.ID).AllPages()
+	if err != nil {
+		return false, fmt.Errorf("unable to query access of VKCS flavor %s: %s", flavor.ID, err)
+	}
+
+	accesses, err := flavors.ExtractAccesses(allPages)
+	if err != nil {
+		return false, fmt.Errorf("unable to retrieve access of VKCS flavor %s: %s", flavor.ID, err)
+	}
+
+	for _, access := range accesses {
+		if access.TenantID == projectID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,28 @@
+package compute_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/acctest"
+)
+
+func TestAccComputeFlavorsDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccComputeFlavorsDataSourceBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.vkcs_compute_flavors.flavors", "id"),
+					resource.TestCheckResourceAttrSet("data.vkcs_compute_flavors.flavors", "flavors.0.id"),
+				),
+			},
+		},
+	})
+}
+
+const testAccComputeFlavorsDataSourceBasic = `
+data "vkcs_compute_flavors" "flavors" {}
+`
@@ -0,0 +1,114 @@
+package compute
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// computeFlavorMicroVersion is the compute API microversion starting with which
+// flavors have a description.
+const computeFlavorMicroVersion = "2.55"
+
+var computeFlavorGenerationRe = regexp.MustCompile(`\d+`)
+
+// smallestComputeFlavorIndex returns index of the flavor with the least amount
+// of RAM. Ties are broken by the least disk and then by the least number of
+// VCPUs.
+func smallestComputeFlavorIndex(allFlavors []FlavorExt) int {
+	resIdx := 0
+	for idx, flavor := range allFlavors {
+		res := allFlavors[resIdx]
+		switch {
+		case flavor.RAM != res.RAM:
+			if flavor.RAM < res.RAM {
+				resIdx = idx
+			}
+		case flavor.Disk != res.Disk:
+			if flavor.Disk < res.Disk {
+				resIdx = idx
+			}
+		case flavor.VCPUs < res.VCPUs:
+			resIdx = idx
+		}
+	}
+	return resIdx
+}
+
+// preferredComputeFlavorsByName returns flavors named after the first name
+// in names that matches any of the flavors.
+func preferredComputeFlavorsByName(allFlavors []FlavorExt, names []string) []FlavorExt {
+	for _, name := range names {
+		var matched []FlavorExt
+		for _, flavor := range allFlavors {
+			if flavor.Name == name {
+				matched = append(matched, flavor)
+			}
+		}
+		if len(matched) > 0 {
+			return matched
+		}
+	}
+	return nil
+}
+
+// computeFlavorGeneration returns generation of the flavor from the extra spec.
+func computeFlavorGeneration(flavor FlavorExt, specKey string) (int, bool) {
+	if spec, ok := flavor.ExtraSpecs[specKey]; ok {
+		if s, ok := spec.(string); ok {
+			if gen, err := strconv.Atoi(computeFlavorGenerationRe.FindString(s)); err == nil {
+				return gen, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// newestComputeFlavorGeneration returns flavors of the newest generation and
+// the generation itself. If no flavor indicates its generation, all flavors are
+// returned with an empty generation.
+func newestComputeFlavorGeneration(allFlavors []FlavorExt, specKey string) ([]FlavorExt, string) {
+	newest, found := 0, false
+	for _, flavor := range allFlavors {
+		if gen, ok := computeFlavorGeneration(flavor, specKey); ok && (!found || gen > newest) {
+			newest, found = gen, true
+		}
+	}
+	if !found {
+		return allFlavors, ""
+	}
+
+	var newestFlavors []FlavorExt
+	for _, flavor := range allFlavors {
+		if gen, ok := computeFlavorGeneration(flavor, specKey); ok && gen == newest {
+			newestFlavors = append(newestFlavors, flavor)
+		}
+	}
+	return newestFlavors, strconv.Itoa(newest)
+}
+
+// normalizeComputeFlavorExtraSpec returns the canonical string form of an
+// extra spec value, so that values returned by the API as numbers or booleans
+// match their string representation in the configuration, e.g. "2", 2 and 2.0
+// are all normalized to "2".
+func normalizeComputeFlavorExtraSpec(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		s := strings.TrimSpace(v)
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		if strings.EqualFold(s, "true") || strings.EqualFold(s, "false") {
+			return strings.ToLower(s)
+		}
+		return s
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(v)
+}
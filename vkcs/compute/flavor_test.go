@@ -0,0 +1,338 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	thclient "github.com/gophercloud/gophercloud/testhelper/client"
+	"github.com/stretchr/testify/assert"
+	iflavors "github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/compute/v2/flavors"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util/errutil"
+)
+
+func TestSmallestComputeFlavorIndex(t *testing.T) {
+	allFlavors := []FlavorExt{
+		{Flavor: flavors.Flavor{ID: "flavor0", RAM: 4096, Disk: 20, VCPUs: 2}},
+		{Flavor: flavors.Flavor{ID: "flavor1", RAM: 2048, Disk: 20, VCPUs: 4}},
+		{Flavor: flavors.Flavor{ID: "flavor2", RAM: 2048, Disk: 20, VCPUs: 2}},
+		{Flavor: flavors.Flavor{ID: "flavor3", RAM: 2048, Disk: 40, VCPUs: 1}},
+	}
+
+	assert.Equal(t, 2, smallestComputeFlavorIndex(allFlavors))
+	assert.Equal(t, 1, smallestComputeFlavorIndex(allFlavors[:2]))
+	assert.Equal(t, 0, smallestComputeFlavorIndex(allFlavors[3:]))
+}
+
+func TestPreferredComputeFlavorsByName(t *testing.T) {
+	allFlavors := []FlavorExt{
+		{Flavor: flavors.Flavor{ID: "flavor0", Name: "Standard-2-4"}},
+		{Flavor: flavors.Flavor{ID: "flavor1", Name: "Basic-1-2-20"}},
+		{Flavor: flavors.Flavor{ID: "flavor2", Name: "Standard-2-4"}},
+	}
+
+	preferred := preferredComputeFlavorsByName(allFlavors, []string{"Unknown", "Standard-2-4", "Basic-1-2-20"})
+	assert.Len(t, preferred, 2)
+	assert.Equal(t, "flavor0", preferred[0].ID)
+	assert.Equal(t, "flavor2", preferred[1].ID)
+
+	preferred = preferredComputeFlavorsByName(allFlavors, []string{"Basic-1-2-20", "Standard-2-4"})
+	assert.Len(t, preferred, 1)
+	assert.Equal(t, "flavor1", preferred[0].ID)
+
+	assert.Empty(t, preferredComputeFlavorsByName(allFlavors, []string{"Unknown"}))
+}
+
+func TestNewestComputeFlavorGeneration(t *testing.T) {
+	allFlavors := []FlavorExt{
+		{Flavor: flavors.Flavor{ID: "flavor0", Name: "Standard-2-4"}},
+		{Flavor: flavors.Flavor{ID: "flavor1", Name: "STD2-2-4"}},
+		{Flavor: flavors.Flavor{ID: "flavor2", Name: "STD3-2-4"}, FlavorExtExtraSpecs: iflavors.FlavorExtExtraSpecs{
+			ExtraSpecs: map[string]interface{}{"mcs:cpu_generation": "gen4"},
+		}},
+		{Flavor: flavors.Flavor{ID: "flavor3", Name: "Advanced-2-4"}, FlavorExtExtraSpecs: iflavors.FlavorExtExtraSpecs{
+			ExtraSpecs: map[string]interface{}{"mcs:cpu_generation": "4"},
+		}},
+	}
+
+	newest, generation := newestComputeFlavorGeneration(allFlavors, "mcs:cpu_generation")
+	assert.Equal(t, "4", generation)
+	assert.Len(t, newest, 2)
+	assert.Equal(t, "flavor2", newest[0].ID)
+	assert.Equal(t, "flavor3", newest[1].ID)
+
+	newest, generation = newestComputeFlavorGeneration(allFlavors[:2], "mcs:cpu_generation")
+	assert.Equal(t, "", generation)
+	assert.Len(t, newest, 2)
+}
+
+func TestFindComputeFlavorsExtraSpecs(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/flavors/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"flavors": [
+			{"id": "flavor0", "name": "Basic-1-2-20", "ram": 2048, "vcpus": 1, "disk": 20, "swap": "",
+			 "extra_specs": {"mcs:cpu_type": "standard"}},
+			{"id": "flavor1", "name": "Basic-1-2-20-hp", "ram": 2048, "vcpus": 1, "disk": 20, "swap": "",
+			 "extra_specs": {"mcs:cpu_type": "high"}}
+		]}`)
+	})
+
+	requiredFlavor := &RequiredFlavor{
+		ExtraSpecs:    map[string]interface{}{"mcs:cpu_type": "standard"},
+		HasExtraSpecs: true,
+	}
+	allFlavors, _, err := findComputeFlavors(thclient.ServiceClient(), flavors.ListOpts{}, requiredFlavor)
+
+	assert.NoError(t, err)
+	assert.Len(t, allFlavors, 1)
+	assert.Equal(t, "Basic-1-2-20", allFlavors[0].Name)
+	assert.Equal(t, map[string]interface{}{"mcs:cpu_type": "standard"}, allFlavors[0].ExtraSpecs)
+}
+
+func TestListComputeFlavorExtraSpecsRetriesRateLimited(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	defaultBackoff := computeFlavorExtraSpecsBackoff
+	computeFlavorExtraSpecsBackoff = time.Millisecond
+	defer func() { computeFlavorExtraSpecsBackoff = defaultBackoff }()
+
+	calls := 0
+	th.Mux.HandleFunc("/flavors/flavor0/os-extra_specs", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		calls++
+		if calls < computeFlavorExtraSpecsAttempts {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"extra_specs": {"hw:cpu_policy": "dedicated"}}`)
+	})
+	th.Mux.HandleFunc("/flavors/flavor1/os-extra_specs", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	es, err := listComputeFlavorExtraSpecs(context.Background(), thclient.ServiceClient(), "flavor0")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"hw:cpu_policy": "dedicated"}, es)
+	assert.Equal(t, computeFlavorExtraSpecsAttempts, calls)
+
+	_, err = listComputeFlavorExtraSpecs(context.Background(), thclient.ServiceClient(), "flavor1")
+	assert.True(t, errutil.Is(err, 429))
+
+	computeFlavorExtraSpecsBackoff = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	_, err = listComputeFlavorExtraSpecs(ctx, thclient.ServiceClient(), "flavor0")
+	assert.True(t, errutil.Is(err, 429))
+	assert.Equal(t, 1, calls)
+}
+
+func TestFindComputeFlavorsMinRAMWithVCPUs(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/flavors/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"flavors": [
+			{"id": "flavor0", "name": "Basic-1-2-20", "ram": 2048, "vcpus": 1, "disk": 20},
+			{"id": "flavor1", "name": "Standard-2-8-50", "ram": 8192, "vcpus": 2, "disk": 50},
+			{"id": "flavor2", "name": "Standard-2-4-50", "ram": 4096, "vcpus": 2, "disk": 50},
+			{"id": "flavor3", "name": "Standard-4-4-50", "ram": 4096, "vcpus": 4, "disk": 50}
+		]}`)
+	})
+
+	requiredFlavor := &RequiredFlavor{MinRAM: 2048, HasMinRAM: true, VCPUs: 2, HasVCPUs: true}
+	allFlavors, _, err := findComputeFlavors(thclient.ServiceClient(), flavors.ListOpts{MinRAM: 2048}, requiredFlavor)
+
+	assert.NoError(t, err)
+	assert.Len(t, allFlavors, 2)
+	assert.Equal(t, "flavor2", allFlavors[smallestComputeFlavorIndex(allFlavors)].ID)
+}
+
+func TestFindComputeFlavorsDescription(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/flavors/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		// Flavors have a description starting with the microversion 2.55.
+		if r.Header.Get("X-OpenStack-Nova-API-Version") != "2.55" {
+			fmt.Fprint(w, `{"flavors": [
+				{"id": "flavor0", "name": "Basic-1-2-20", "ram": 2048, "vcpus": 1, "disk": 20, "swap": ""}
+			]}`)
+			return
+		}
+		fmt.Fprint(w, `{"flavors": [
+			{"id": "flavor0", "name": "Basic-1-2-20", "ram": 2048, "vcpus": 1, "disk": 20, "swap": "",
+			 "description": "Basic flavor"}
+		]}`)
+	})
+
+	computeClient := thclient.ServiceClient()
+	computeClient.Type = "compute"
+	computeClient.Microversion = computeFlavorMicroVersion
+	allFlavors, _, err := findComputeFlavors(computeClient, flavors.ListOpts{}, &RequiredFlavor{})
+
+	assert.NoError(t, err)
+	assert.Len(t, allFlavors, 1)
+	assert.Equal(t, "Basic-1-2-20", allFlavors[0].Name)
+	assert.Equal(t, "Basic flavor", allFlavors[0].Description)
+}
+
+func TestFindComputeFlavorsNameWithMinRAM(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/flavors/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"flavors": [
+			{"id": "flavor0", "name": "Basic-1-2-20", "ram": 2048, "vcpus": 1, "disk": 20},
+			{"id": "flavor1", "name": "Standard-2-8-50", "ram": 8192, "vcpus": 2, "disk": 50}
+		]}`)
+	})
+
+	requiredFlavor := &RequiredFlavor{Name: "Standard-2-8-50", HasName: true, MinRAM: 4096, HasMinRAM: true}
+	allFlavors, _, err := findComputeFlavors(thclient.ServiceClient(), flavors.ListOpts{}, requiredFlavor)
+	assert.NoError(t, err)
+	assert.Len(t, allFlavors, 1)
+
+	requiredFlavor = &RequiredFlavor{Name: "Basic-1-2-20", HasName: true, MinRAM: 4096, HasMinRAM: true}
+	_, _, err = findComputeFlavors(thclient.ServiceClient(), flavors.ListOpts{}, requiredFlavor)
+	assert.EqualError(t, err, "flavor Basic-1-2-20 has 2048 MB of RAM, but min_ram = 4096 is requested")
+}
+
+func TestFindComputeFlavorsNotListed(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/flavors/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"flavors": [
+			{"id": "flavor0", "name": "Basic-1-2-20", "ram": 2048, "vcpus": 1, "disk": 20}
+		]}`)
+	})
+
+	requiredFlavor := &RequiredFlavor{Name: "Basic-1-2-20", HasName: true, VCPUs: 2, HasVCPUs: true}
+	allFlavors, _, err := findComputeFlavors(thclient.ServiceClient(), flavors.ListOpts{}, requiredFlavor)
+	assert.NoError(t, err)
+	assert.Empty(t, allFlavors)
+
+	requiredFlavor = &RequiredFlavor{Name: "Standard-2-8-50", HasName: true}
+	_, _, err = findComputeFlavors(thclient.ServiceClient(), flavors.ListOpts{}, requiredFlavor)
+	assert.ErrorIs(t, err, errComputeFlavorNotListed)
+
+	_, _, err = findComputeFlavors(thclient.ServiceClient(), flavors.ListOpts{MinDisk: 50}, requiredFlavor)
+	assert.NoError(t, err)
+}
+
+func TestFindComputeFlavorsStrictExtraSpecs(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/flavors/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"flavors": [
+			{"id": "flavor0", "name": "Basic-1-2-20", "ram": 2048, "vcpus": 1, "disk": 20, "extra_specs": {"mcs:cpu_type": "standard"}}
+		]}`)
+	})
+
+	requiredFlavor := &RequiredFlavor{
+		ExtraSpecs:       map[string]interface{}{"mcs:cpu_type": "standard", "mcs:cpu_typo": "standard"},
+		HasExtraSpecs:    true,
+		StrictExtraSpecs: true,
+	}
+	allFlavors, unknownSpecs, err := findComputeFlavors(thclient.ServiceClient(), flavors.ListOpts{}, requiredFlavor)
+
+	assert.NoError(t, err)
+	assert.Empty(t, allFlavors)
+	assert.Equal(t, []string{"mcs:cpu_typo"}, unknownSpecs)
+}
+
+func TestNormalizeComputeFlavorExtraSpec(t *testing.T) {
+	for _, v := range []interface{}{"2", 2, 2.0, float64(2), " 2 ", "2.0"} {
+		assert.Equal(t, "2", normalizeComputeFlavorExtraSpec(v), "%#v", v)
+	}
+	for _, v := range []interface{}{"true", "True", true} {
+		assert.Equal(t, "true", normalizeComputeFlavorExtraSpec(v), "%#v", v)
+	}
+	assert.Equal(t, "0.5", normalizeComputeFlavorExtraSpec(0.5))
+	assert.Equal(t, "standard", normalizeComputeFlavorExtraSpec("standard"))
+	assert.Equal(t, "1", normalizeComputeFlavorExtraSpec("1"))
+	assert.NotEqual(t, normalizeComputeFlavorExtraSpec("2"), normalizeComputeFlavorExtraSpec("20"))
+}
+
+func TestCheckComputeFlavorRegion(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	calls := 0
+	th.Mux.HandleFunc("/regions/RegionOne", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		calls++
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"region": {"id": "RegionOne"}}`)
+	})
+	missingCalls := 0
+	th.Mux.HandleFunc("/regions/RegionTwo", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		missingCalls++
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	assert.NoError(t, checkComputeFlavorRegion(thclient.ServiceClient(), "RegionOne"))
+	assert.NoError(t, checkComputeFlavorRegion(thclient.ServiceClient(), "RegionOne"))
+	assert.Equal(t, 1, calls)
+	assert.EqualError(t, checkComputeFlavorRegion(thclient.ServiceClient(), "RegionTwo"), "region RegionTwo does not exist")
+	assert.EqualError(t, checkComputeFlavorRegion(thclient.ServiceClient(), "RegionTwo"), "region RegionTwo does not exist")
+	assert.Equal(t, 2, missingCalls)
+}
+
+func TestListComputeFlavorsSharedWithProject(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/flavors/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestFormValues(t, r, map[string]string{"is_public": "None"})
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"flavors": [
+			{"id": "flavor2", "name": "Private-2", "os-flavor-access:is_public": false},
+			{"id": "flavor1", "name": "Private-1", "os-flavor-access:is_public": false},
+			{"id": "flavor0", "name": "Basic-1-2-20", "os-flavor-access:is_public": true}
+		]}`)
+	})
+	for flavorID, projectID := range map[string]string{"flavor1": "project0", "flavor2": "project1"} {
+		projectID := projectID
+		th.Mux.HandleFunc("/flavors/"+flavorID+"/os-flavor-access", func(w http.ResponseWriter, r *http.Request) {
+			th.TestMethod(t, r, "GET")
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"flavor_access": [{"flavor_id": "flavor", "tenant_id": %q}]}`, projectID)
+		})
+	}
+
+	allFlavors, err := listComputeFlavors(thclient.ServiceClient(), "")
+	assert.NoError(t, err)
+	assert.Len(t, allFlavors, 3)
+	assert.Equal(t, "flavor0", allFlavors[0].ID)
+
+	allFlavors, err = listComputeFlavors(thclient.ServiceClient(), "project1")
+	assert.NoError(t, err)
+	assert.Len(t, allFlavors, 1)
+	assert.Equal(t, "Private-2", allFlavors[0].Name)
+}
@@ -2,20 +2,48 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/compute/v2/servers"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/backups"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/clusters"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/instances"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/lb/v2/loadbalancers"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util/errutil"
 )
 
+const dbClusterLogSubsystem = "db_cluster"
+
+// logDatabaseClusterRequest writes a debug message with the request id of
+// a Databases API call. The messages go to the "db_cluster" log subsystem,
+// so their level can be set with TF_LOG_PROVIDER_VKCS_DB_CLUSTER variable.
+func logDatabaseClusterRequest(ctx context.Context, clusterID string, msg string, header http.Header) {
+	ctx = tflog.NewSubsystem(ctx, dbClusterLogSubsystem,
+		tflog.WithLevelFromEnv("TF_LOG_PROVIDER_VKCS", dbClusterLogSubsystem),
+		tflog.WithAdditionalLocationOffset(1),
+		tflog.WithRootFields(),
+	)
+	tflog.SubsystemDebug(ctx, dbClusterLogSubsystem, msg, map[string]interface{}{
+		"cluster_id": clusterID,
+		"request_id": header.Get(util.RequestIDHeader),
+	})
+}
+
 func flattenDatabaseClusterWalVolume(w instances.WalVolume) []map[string]interface{} {
 	walvolume := make([]map[string]interface{}, 1)
 	walvolume[0] = make(map[string]interface{})
@@ -53,16 +81,160 @@ func flattenDatabaseClusterShard(id string, shardInsts []clusters.ClusterInstanc
 	shard := make(map[string]interface{})
 	shard["shard_id"] = id
 	shard["size"] = len(shardInsts)
-	shard["flavor_id"] = shardInsts[0].Flavor.ID
+	shard["flavor_id"] = databaseClusterShardFlavorID(shardInsts, "")
 	shard["volume_size"] = shardInsts[0].Volume.Size
 	shard["volume_type"] = shardInsts[0].Volume.VolumeType
 	if walVolume := shardInsts[0].WalVolume; walVolume != nil {
 		shard["wal_volume"] = flattenDatabaseClusterWalVolume(*walVolume)
 	}
 	shard["instances"] = flattenDatabaseClusterShardInstances(shardInsts)
+	shard["instance_count"] = countDatabaseClusterActiveInstances(shardInsts)
+	shard["leader"] = databaseClusterShardLeaderID(shardInsts)
 	return shard
 }
 
+// databaseClusterShardLeaderID returns the ID of the leader instance of the
+// shard or an empty string if the shard has no leader, e.g. during failover.
+func databaseClusterShardLeaderID(shardInsts []clusters.ClusterInstanceResp) string {
+	for _, inst := range shardInsts {
+		if inst.Role == DBClusterInstanceRoleLeader {
+			return inst.ID
+		}
+	}
+	return ""
+}
+
+func countDatabaseClusterActiveInstances(insts []clusters.ClusterInstanceResp) (count int) {
+	for _, inst := range insts {
+		if inst.Status == string(dbInstanceStatusActive) {
+			count++
+		}
+	}
+	return
+}
+
+// databaseClusterShardFlavorID returns flavor of the shard instances. If some
+// instance has a flavor other than knownFlavorID, e.g. it was resized outside
+// of terraform, that flavor is returned to reveal the drift.
+func databaseClusterShardFlavorID(shardInsts []clusters.ClusterInstanceResp, knownFlavorID string) string {
+	for _, inst := range shardInsts {
+		if inst.Flavor == nil {
+			continue
+		}
+		if inst.Flavor.ID != knownFlavorID {
+			return inst.Flavor.ID
+		}
+	}
+	return knownFlavorID
+}
+
+// getDatabaseClusterShardInstanceDetails returns details of the shard
+// instances, which the cluster response lacks.
+func getDatabaseClusterShardInstanceDetails(client *gophercloud.ServiceClient, shardInsts []clusters.ClusterInstanceResp) ([]*instances.InstanceResp, error) {
+	details := make([]*instances.InstanceResp, 0, len(shardInsts))
+	for _, clusterInst := range shardInsts {
+		inst, err := instances.Get(client, clusterInst.ID).Extract()
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, inst)
+	}
+	return details, nil
+}
+
+// databaseClusterShardDatastoreVersion returns datastore version of the shard
+// instances. If some instance runs a version other than clusterVersion, e.g.
+// in the middle of an upgrade, that version is returned to reveal it.
+func databaseClusterShardDatastoreVersion(shardInsts []*instances.InstanceResp, clusterVersion string) string {
+	for _, inst := range shardInsts {
+		if inst.DataStore != nil && inst.DataStore.Version != clusterVersion {
+			return inst.DataStore.Version
+		}
+	}
+	return clusterVersion
+}
+
+// databaseClusterShardUpdatedAt returns the time the shard instances were
+// last modified in RFC3339 format or an empty string if it is unknown.
+func databaseClusterShardUpdatedAt(shardInsts []*instances.InstanceResp) string {
+	var updated time.Time
+	for _, inst := range shardInsts {
+		if inst.Updated.After(updated) {
+			updated = inst.Updated.Time
+		}
+	}
+	if updated.IsZero() {
+		return ""
+	}
+	return updated.Format(time.RFC3339)
+}
+
+// flattenDatabaseClusterEffectiveCapabilities flattens capabilities reported
+// by the server together with their statuses.
+func flattenDatabaseClusterEffectiveCapabilities(c []instances.DatabaseCapability) []map[string]interface{} {
+	capabilities := flattenDatabaseInstanceCapabilities(c)
+	for i, capability := range c {
+		capabilities[i]["status"] = capability.Status
+	}
+	return capabilities
+}
+
+// databaseClusterEffectiveKeypair returns keypair of compute instances of the
+// cluster. If some instance has a keypair other than knownKeypair, e.g. it was
+// replaced outside of terraform, that keypair is returned to reveal the drift.
+func databaseClusterEffectiveKeypair(client *gophercloud.ServiceClient, insts []clusters.ClusterInstanceResp, knownKeypair string) (string, error) {
+	for _, inst := range insts {
+		if inst.СomputeInstanceID == "" {
+			continue
+		}
+		server, err := servers.Get(client, inst.СomputeInstanceID).Extract()
+		if err != nil {
+			return knownKeypair, err
+		}
+		if server.KeyName != knownKeypair {
+			return server.KeyName, nil
+		}
+	}
+	return knownKeypair, nil
+}
+
+// databaseClusterOrphanInstanceIDs returns sorted IDs of instances that are
+// not assigned to any shard.
+func databaseClusterOrphanInstanceIDs(shardsInstances map[string][]clusters.ClusterInstanceResp) []string {
+	var ids []string
+	for _, inst := range shardsInstances[""] {
+		ids = append(ids, inst.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// checkDatabaseClusterInstanceCount makes sure that the created cluster has
+// as many instances as were requested, since the cluster may become active
+// with a part of instances missing.
+func checkDatabaseClusterInstanceCount(client *gophercloud.ServiceClient, clusterID string, expected int) error {
+	cluster, err := clusters.Get(client, clusterID).Extract()
+	if err != nil {
+		return fmt.Errorf("error retrieving cluster %s: %s", clusterID, err)
+	}
+	if len(cluster.Instances) == expected {
+		return nil
+	}
+
+	shardsInstances := getDatabaseClusterShardInstances(cluster.Instances)
+	shardIDs := make([]string, 0, len(shardsInstances))
+	for shardID := range shardsInstances {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Strings(shardIDs)
+	counts := make([]string, 0, len(shardIDs))
+	for _, shardID := range shardIDs {
+		counts = append(counts, fmt.Sprintf("%s: %d", shardID, len(shardsInstances[shardID])))
+	}
+	return fmt.Errorf("cluster %s has %d instances, but %d were requested (instances by shard: %s)",
+		clusterID, len(cluster.Instances), expected, strings.Join(counts, ", "))
+}
+
 func getDatabaseClusterShardInstances(insts []clusters.ClusterInstanceResp) map[string][]clusters.ClusterInstanceResp {
 	shardsInstances := make(map[string][]clusters.ClusterInstanceResp)
 	for _, inst := range insts {
@@ -85,6 +257,107 @@ func flattenDatabaseClusterShardInstance(inst clusters.ClusterInstanceResp) map[
 	return instance
 }
 
+// getDatabaseClusterInstancePort returns the port of the compute instance of
+// a database instance that has one of the instance IPs or nil if the port is
+// not found. If the instance has no IPs, the compute instance must have a
+// single port.
+func getDatabaseClusterInstancePort(client *gophercloud.ServiceClient, computeInstanceID string, ips []string) (*ports.Port, error) {
+	if computeInstanceID == "" {
+		return nil, nil
+	}
+
+	allPages, err := ports.List(client, ports.ListOpts{DeviceID: computeInstanceID}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	allPorts, err := ports.ExtractPorts(allPages)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []ports.Port
+	for _, port := range allPorts {
+		if len(ips) == 0 || databaseClusterPortHasIP(port, ips) {
+			matched = append(matched, port)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &matched[0], nil
+	}
+	return nil, fmt.Errorf("found %d ports of compute instance %s matching IPs %v", len(matched), computeInstanceID, ips)
+}
+
+func databaseClusterPortHasIP(port ports.Port, ips []string) bool {
+	for _, fixedIP := range port.FixedIPs {
+		for _, ip := range ips {
+			if fixedIP.IPAddress == ip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getDatabaseClusterWriteEndpoint returns the VIP address of the loadbalancer
+// attached to the cluster.
+func getDatabaseClusterWriteEndpoint(client *gophercloud.ServiceClient, loadbalancerID string) (string, error) {
+	lb, err := loadbalancers.Get(client, loadbalancerID).Extract()
+	if err != nil {
+		return "", err
+	}
+	return lb.VipAddress, nil
+}
+
+// getDatabaseClusterPortFloatingIP returns address of the floating IP
+// associated with the port or an empty string if there is none.
+func getDatabaseClusterPortFloatingIP(client *gophercloud.ServiceClient, portID string) (string, error) {
+	allPages, err := floatingips.List(client, floatingips.ListOpts{PortID: portID}).AllPages()
+	if err != nil {
+		return "", err
+	}
+	allFips, err := floatingips.ExtractFloatingIPs(allPages)
+	if err != nil {
+		return "", err
+	}
+	if len(allFips) == 0 {
+		return "", nil
+	}
+	return allFips[0].FloatingIP, nil
+}
+
+// databaseClusterCreateProcessError turns an error of cluster creation caused
+// by an exceeded quota, which the Databases API reports with 413 status, into
+// a diagnostic carrying the message of the API, so that it is clear what to
+// free up or request. Other errors are returned as is.
+func databaseClusterCreateProcessError(err error, resourceName string) diag.Diagnostics {
+	var respErr gophercloud.ErrUnexpectedResponseCode
+	if !errutil.Is(err, http.StatusRequestEntityTooLarge) || !errors.As(err, &respErr) {
+		return diag.Errorf("error creating %s: %s", resourceName, err)
+	}
+
+	detail := "The project does not have enough quota to create the cluster."
+	var body map[string]struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(respErr.Body, &body) == nil {
+		for _, fault := range body {
+			if fault.Message != "" {
+				detail += " " + fault.Message
+			}
+		}
+	}
+	detail += fmt.Sprintf(" Free up resources or request a quota increase and retry.\n\nDatabases API response: %s", err)
+
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("Quota exceeded creating %s", resourceName),
+		Detail:   detail,
+	}}
+}
+
 func expandDatabaseClusterShrinkOptions(v []interface{}) []string {
 	opts := make([]string, len(v))
 	for i, opt := range v {
@@ -115,6 +388,9 @@ func databaseClusterDetermineShrinkedInstances(toDelete int, shrinkOptions []str
 			return nil, fmt.Errorf("invalid shrink options: %s", err)
 		}
 		for _, instance := range instances {
+			if instance.ShardID != shardID {
+				continue
+			}
 			needToRemain := false
 			for _, opt := range shrinkOptions {
 				if instance.ID == opt {
@@ -152,6 +428,69 @@ func databaseClusterValidateShrinkOptions(shrinkOptions []string, instances []cl
 	return nil
 }
 
+// databaseClusterRevertFailedShards returns newShards where the shards that
+// failed to update are replaced with their previous state.
+func databaseClusterRevertFailedShards(oldShards, newShards []interface{}, failedShardIDs map[string]bool) []interface{} {
+	oldByID := make(map[string]interface{}, len(oldShards))
+	for _, oldShard := range oldShards {
+		oldByID[oldShard.(map[string]interface{})["shard_id"].(string)] = oldShard
+	}
+	shards := make([]interface{}, len(newShards))
+	for i, newShard := range newShards {
+		shards[i] = newShard
+		shardID := newShard.(map[string]interface{})["shard_id"].(string)
+		if oldShard, ok := oldByID[shardID]; ok && failedShardIDs[shardID] {
+			shards[i] = oldShard
+		}
+	}
+	return shards
+}
+
+func flattenDatabaseClusterShardIDs(shards []map[string]interface{}) []string {
+	shardIDs := make([]string, len(shards))
+	for i, shard := range shards {
+		shardIDs[i], _ = shard["shard_id"].(string)
+	}
+	return shardIDs
+}
+
+// databaseClusterBackupScheduleUnsupported reports whether the error of
+// reading the backup schedule means that the cluster has no schedule, either
+// because none is set or because the datastore does not support schedules.
+func databaseClusterBackupScheduleUnsupported(err error) bool {
+	return errutil.Any(err, []int{http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented})
+}
+
+// getDatabaseClusterBackups returns flattened backups of the cluster. Backups
+// are still filtered by the cluster, since the API may ignore the filter and
+// list backups of the whole project.
+func getDatabaseClusterBackups(client *gophercloud.ServiceClient, clusterID string) ([]map[string]interface{}, error) {
+	allPages, err := backups.List(client, backups.ListOpts{ClusterID: clusterID}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	allBackups, err := backups.ExtractBackups(allPages)
+	if err != nil {
+		return nil, err
+	}
+	return flattenDatabaseClusterBackups(allBackups, clusterID), nil
+}
+
+func flattenDatabaseClusterBackups(allBackups []backups.BackupResp, clusterID string) []map[string]interface{} {
+	clusterBackups := make([]map[string]interface{}, 0)
+	for _, b := range allBackups {
+		if b.ClusterID != clusterID {
+			continue
+		}
+		clusterBackups = append(clusterBackups, map[string]interface{}{
+			"id":      b.ID,
+			"created": b.Created,
+			"size":    b.Size,
+		})
+	}
+	return clusterBackups
+}
+
 func databaseClusterExpandShards(d *schema.ResourceData) (r []map[string]interface{}) {
 	shardsRaw := d.Get("shard").([]interface{})
 	for _, shRaw := range shardsRaw {
@@ -204,6 +543,56 @@ func (uCtx *dbResourceUpdateContext) WaitForStateContext() error {
 	return nil
 }
 
+// databaseClusterShardUpdateContext returns update context for actions of the
+// shard. If operation_timeout of the shard is set, it overrides the timeout of
+// the resource.
+func databaseClusterShardUpdateContext(updateCtx *dbResourceUpdateContext, shard map[string]interface{}) *dbResourceUpdateContext {
+	operationTimeout, _ := shard["operation_timeout"].(string)
+	timeout, err := time.ParseDuration(operationTimeout)
+	if err != nil || timeout <= 0 {
+		return updateCtx
+	}
+	stateConf := *updateCtx.StateConf
+	stateConf.Timeout = timeout
+	return &dbResourceUpdateContext{
+		Ctx:       updateCtx.Ctx,
+		Client:    updateCtx.Client,
+		D:         updateCtx.D,
+		StateConf: &stateConf,
+	}
+}
+
+// databaseClusterWaitForPendingOperation waits for an operation that was
+// started before, e.g. by an interrupted apply, to finish, so that new
+// actions are not rejected because the cluster is busy.
+func databaseClusterWaitForPendingOperation(updateCtx *dbResourceUpdateContext) error {
+	clusterID := updateCtx.D.Id()
+	r := clusters.Get(updateCtx.Client, clusterID)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to read cluster", r.Header)
+	cluster, err := r.Extract()
+	if err != nil {
+		return fmt.Errorf("%w: %s", errDBClusterNotFound, err)
+	}
+
+	status := getClusterStatus(cluster)
+	if status == string(dbClusterStatusActive) {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Cluster %s has pending operation in status %s, waiting for it to finish", clusterID, status)
+	updateCtx.StateConf.Pending = []string{
+		string(dbClusterStatusBuild),
+		string(dbClusterStatusGrow),
+		string(dbClusterStatusResize),
+		string(dbClusterStatusShrink),
+		string(dbClusterStatusUpdating),
+		string(dbClusterStatusCapabilityApplying),
+		string(dbClusterStatusBackup),
+	}
+	updateCtx.StateConf.Target = []string{string(dbClusterStatusActive)}
+	return updateCtx.WaitForStateContext()
+}
+
 var (
 	errDBClusterNotFound      = errors.New("cluster not found")
 	errDBClusterShardNotFound = errors.New("unable to determine shard")
@@ -214,6 +603,8 @@ var (
 	errDBClusterUpdateWalDiskAutoexpand        = errors.New("error updating wal_disk_autoexpand")
 	errDBClusterUpdateWalDiskAutoexpandExtract = errors.New("unable to determine wal_disk_autoexpand")
 	errDBClusterUpdateCloudMonitoring          = errors.New("error updating cloud_monitoring_enabled")
+	errDBClusterUpdateBackupSchedule           = errors.New("error updating backup_schedule")
+	errDBClusterUpdateBackupScheduleExtract    = errors.New("unable to determine backup_schedule")
 
 	errDBClusterActionUpdateConfiguration      = errors.New("error updating configuration for cluster")
 	errDBClusterActionApplyCapabitilies        = errors.New("error applying capabilities")
@@ -228,27 +619,34 @@ var (
 	errDBClusterActionResizeFlavor             = errors.New("error resizing flavor")
 )
 
-func databaseClusterActionUpdateConfiguration(updateCtx *dbResourceUpdateContext) error {
-	old, new := updateCtx.D.GetChange("configuration_id")
-
-	var restartConfirmed *bool
-	vendorOptionsRaw := updateCtx.D.Get("vendor_options").(*schema.Set)
+func databaseClusterRestartConfirmed(d *schema.ResourceData) *bool {
+	vendorOptionsRaw := d.Get("vendor_options").(*schema.Set)
 	if vendorOptionsRaw.Len() > 0 {
 		vendorOptions := util.ExpandVendorOptions(vendorOptionsRaw.List())
 		if v, ok := vendorOptions["restart_confirmed"]; ok || v.(bool) {
-			rC := true
-			restartConfirmed = &rC
+			restartConfirmed := true
+			return &restartConfirmed
 		}
 	}
+	return nil
+}
+
+func databaseClusterActionUpdateConfiguration(updateCtx *dbResourceUpdateContext) error {
+	old, new := updateCtx.D.GetChange("configuration_id")
+	return databaseClusterActionUpdateConfigurationByID(updateCtx, old.(string), new.(string))
+}
+
+func databaseClusterActionUpdateConfigurationByID(updateCtx *dbResourceUpdateContext, oldID, newID string) error {
+	restartConfirmed := databaseClusterRestartConfirmed(updateCtx.D)
 
 	var detachOpts clusters.DetachConfigurationGroupOpts
-	detachOpts.ConfigurationDetach.ConfigurationID = old.(string)
+	detachOpts.ConfigurationDetach.ConfigurationID = oldID
 	detachOpts.ConfigurationDetach.RestartConfirmed = restartConfirmed
 
 	var attachOpts *clusters.AttachConfigurationGroupOpts
-	if new != "" {
+	if newID != "" {
 		attachOpts = &clusters.AttachConfigurationGroupOpts{}
-		attachOpts.ConfigurationAttach.ConfigurationID = new.(string)
+		attachOpts.ConfigurationAttach.ConfigurationID = newID
 		attachOpts.ConfigurationAttach.RestartConfirmed = restartConfirmed
 	}
 
@@ -258,7 +656,9 @@ func databaseClusterActionUpdateConfiguration(updateCtx *dbResourceUpdateContext
 func databaseClusterActionUpdateConfigurationBase(updateCtx *dbResourceUpdateContext, detachOpts *clusters.DetachConfigurationGroupOpts, attachOpts *clusters.AttachConfigurationGroupOpts) error {
 	dbClient, clusterID := updateCtx.Client, updateCtx.D.Id()
 
-	err := clusters.ClusterAction(dbClient, clusterID, detachOpts).ExtractErr()
+	r := clusters.ClusterAction(dbClient, clusterID, detachOpts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to detach configuration", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterActionUpdateConfiguration, err)
 	}
@@ -273,7 +673,9 @@ func databaseClusterActionUpdateConfigurationBase(updateCtx *dbResourceUpdateCon
 	}
 
 	if attachOpts != nil {
-		err := clusters.ClusterAction(dbClient, clusterID, attachOpts).ExtractErr()
+		r := clusters.ClusterAction(dbClient, clusterID, attachOpts)
+		logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to attach configuration", r.Header)
+		err := r.ExtractErr()
 		if err != nil {
 			return fmt.Errorf("%w: %s", errDBClusterActionUpdateConfiguration, err)
 		}
@@ -306,7 +708,9 @@ func databaseClusterUpdateDiskAutoexpand(updateCtx *dbResourceUpdateContext) err
 func databaseClusterUpdateDiskAutoexpandBase(updateCtx *dbResourceUpdateContext, autoExpandOpts clusters.UpdateAutoExpandOpts) error {
 	dbClient, clusterID := updateCtx.Client, updateCtx.D.Id()
 
-	err := clusters.UpdateAutoExpand(dbClient, clusterID, &autoExpandOpts).ExtractErr()
+	r := clusters.UpdateAutoExpand(dbClient, clusterID, &autoExpandOpts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to update disk autoexpand", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterUpdateDiskAutoexpand, err)
 	}
@@ -318,6 +722,87 @@ func databaseClusterUpdateDiskAutoexpandBase(updateCtx *dbResourceUpdateContext,
 	return updateCtx.WaitForStateContext()
 }
 
+// databaseClusterSyncInstancesDiskAutoexpand makes sure that volume of every
+// cluster instance has autoresize properties of its shard or, if the shard
+// doesn't override them, of the cluster.
+func databaseClusterSyncInstancesDiskAutoexpand(updateCtx *dbResourceUpdateContext) error {
+	d := updateCtx.D
+	clusterOpts, err := expandDatabaseClusterInstanceAutoExpandOpts(d.Get("disk_autoexpand").([]interface{}))
+	if err != nil {
+		return errDBClusterUpdateDiskAutoexpandExtract
+	}
+	shardsOpts := make(map[string]*instances.UpdateAutoExpandOpts)
+	for _, shardRaw := range d.Get("shard").([]interface{}) {
+		shard := shardRaw.(map[string]interface{})
+		shardOpts, err := expandDatabaseClusterInstanceAutoExpandOpts(shard["disk_autoexpand"].([]interface{}))
+		if err != nil {
+			return errDBClusterUpdateDiskAutoexpandExtract
+		}
+		if shardOpts != nil {
+			shardsOpts[shard["shard_id"].(string)] = shardOpts
+		}
+	}
+
+	dbClient, clusterID := updateCtx.Client, d.Id()
+	cluster, err := clusters.Get(dbClient, clusterID).Extract()
+	if err != nil {
+		return fmt.Errorf("%w: %s", errDBClusterNotFound, err)
+	}
+
+	var updated bool
+	for _, clusterInst := range cluster.Instances {
+		autoExpandOpts, ok := shardsOpts[clusterInst.ShardID]
+		if !ok {
+			autoExpandOpts = clusterOpts
+		}
+		if autoExpandOpts == nil {
+			continue
+		}
+
+		inst, err := instances.Get(dbClient, clusterInst.ID).Extract()
+		if err != nil {
+			return fmt.Errorf("%w: %s", errDBClusterUpdateDiskAutoexpand, err)
+		}
+		if inst.AutoExpand == autoExpandOpts.Instance.VolumeAutoresizeEnabled && inst.MaxDiskSize == autoExpandOpts.Instance.VolumeAutoresizeMaxSize {
+			continue
+		}
+
+		log.Printf("[DEBUG] Updating disk_autoexpand of instance %s of cluster %s", inst.ID, clusterID)
+		r := instances.UpdateAutoExpand(dbClient, inst.ID, autoExpandOpts)
+		logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to update instance disk autoexpand", r.Header)
+		if err := r.ExtractErr(); err != nil {
+			return fmt.Errorf("%w: %s", errDBClusterUpdateDiskAutoexpand, err)
+		}
+		updated = true
+	}
+
+	if !updated {
+		return nil
+	}
+
+	updateCtx.StateConf.Pending = []string{string(dbClusterStatusUpdating)}
+	updateCtx.StateConf.Target = []string{string(dbClusterStatusActive)}
+
+	return updateCtx.WaitForStateContext()
+}
+
+func expandDatabaseClusterInstanceAutoExpandOpts(v []interface{}) (*instances.UpdateAutoExpandOpts, error) {
+	if len(v) == 0 || v[0] == nil {
+		return nil, nil
+	}
+	autoExpandProperties, err := extractDatabaseAutoExpand(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var autoExpandOpts instances.UpdateAutoExpandOpts
+	if autoExpandProperties.AutoExpand {
+		autoExpandOpts.Instance.VolumeAutoresizeEnabled = 1
+	}
+	autoExpandOpts.Instance.VolumeAutoresizeMaxSize = autoExpandProperties.MaxDiskSize
+	return &autoExpandOpts, nil
+}
+
 func databaseClusterUpdateWalDiskAutoexpand(updateCtx *dbResourceUpdateContext) error {
 	walDiskAutoexp := updateCtx.D.Get("wal_disk_autoexpand")
 	walAutoExpandProperties, err := extractDatabaseAutoExpand(walDiskAutoexp.([]interface{}))
@@ -339,7 +824,9 @@ func databaseClusterUpdateWalDiskAutoexpand(updateCtx *dbResourceUpdateContext)
 func databaseClusterUpdateWalDiskAutoexpandBase(updateCtx *dbResourceUpdateContext, walAutoExpandOpts clusters.UpdateAutoExpandWalOpts) error {
 	dbClient, clusterID := updateCtx.Client, updateCtx.D.Id()
 
-	err := clusters.UpdateAutoExpand(dbClient, clusterID, &walAutoExpandOpts).ExtractErr()
+	r := clusters.UpdateAutoExpand(dbClient, clusterID, &walAutoExpandOpts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to update wal disk autoexpand", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterUpdateWalDiskAutoexpand, err)
 	}
@@ -360,7 +847,9 @@ func databaseClusterUpdateCloudMonitoring(updateCtx *dbResourceUpdateContext) er
 
 func databaseClusterUpdateCloudMonitoringBase(updateCtx *dbResourceUpdateContext, cloudMonitoringOpts clusters.UpdateCloudMonitoringOpts) error {
 	clusterID := updateCtx.D.Id()
-	err := clusters.ClusterAction(updateCtx.Client, clusterID, &cloudMonitoringOpts).ExtractErr()
+	r := clusters.ClusterAction(updateCtx.Client, clusterID, &cloudMonitoringOpts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to update cloud monitoring", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterUpdateCloudMonitoring, err)
 	}
@@ -368,6 +857,32 @@ func databaseClusterUpdateCloudMonitoringBase(updateCtx *dbResourceUpdateContext
 	return nil
 }
 
+func databaseClusterUpdateBackupSchedule(updateCtx *dbResourceUpdateContext) error {
+	dbClient, clusterID := updateCtx.Client, updateCtx.D.Id()
+
+	v := updateCtx.D.Get("backup_schedule").([]interface{})
+	if len(v) == 0 {
+		return fmt.Errorf("%w: removing the schedule is not supported", errDBClusterUpdateBackupScheduleExtract)
+	}
+	backupScheduleUpdateOpts, err := extractDatabaseBackupSchedule(v)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errDBClusterUpdateBackupScheduleExtract, err)
+	}
+
+	r := clusters.UpdateBackupSchedule(dbClient, clusterID, &backupScheduleUpdateOpts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to update backup schedule", r.Header)
+	err = r.ExtractErr()
+	if err != nil {
+		return fmt.Errorf("%w: %s", errDBClusterUpdateBackupSchedule, err)
+	}
+
+	updateCtx.StateConf.Pending = []string{string(dbClusterStatusUpdating), string(dbClusterStatusBackup)}
+	updateCtx.StateConf.Target = []string{string(dbClusterStatusActive)}
+
+	log.Printf("[DEBUG] Waiting for cluster %s to become ready after updating backup_schedule", clusterID)
+	return updateCtx.WaitForStateContext()
+}
+
 func databaseClusterActionApplyCapabilities(updateCtx *dbResourceUpdateContext) error {
 	dbClient, clusterID := updateCtx.Client, updateCtx.D.Id()
 
@@ -388,7 +903,9 @@ func databaseClusterActionApplyCapabilities(updateCtx *dbResourceUpdateContext)
 func databaseClusterActionApplyCapabilitiesBase(updateCtx *dbResourceUpdateContext, applyCapabilityOpts clusters.ApplyCapabilityOpts) error {
 	dbClient, clusterID := updateCtx.Client, updateCtx.D.Id()
 
-	err := clusters.ClusterAction(dbClient, clusterID, &applyCapabilityOpts).ExtractErr()
+	r := clusters.ClusterAction(dbClient, clusterID, &applyCapabilityOpts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to apply capabilities", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterActionApplyCapabitilies, err)
 	}
@@ -401,6 +918,18 @@ func databaseClusterActionApplyCapabilitiesBase(updateCtx *dbResourceUpdateConte
 }
 
 func databaseClusterActionGrow(updateCtx *dbResourceUpdateContext, shardID string) error {
+	pathPrefix, err := shardPathPrefix(updateCtx.D, shardID)
+	if err != nil {
+		return err
+	}
+
+	return databaseClusterActionGrowWithFlavorID(updateCtx, shardID, updateCtx.D.Get(pathPrefix+"flavor_id").(string))
+}
+
+// databaseClusterActionGrowWithFlavorID grows the shard with instances of the
+// flavor, which may differ from flavor_id of the shard in the plan, e.g. when
+// the flavor is resolved from flavor_name.
+func databaseClusterActionGrowWithFlavorID(updateCtx *dbResourceUpdateContext, shardID, flavorID string) error {
 	d := updateCtx.D
 	pathPrefix, err := shardPathPrefix(d, shardID)
 	if err != nil {
@@ -411,7 +940,7 @@ func databaseClusterActionGrow(updateCtx *dbResourceUpdateContext, shardID strin
 	growOpts := clusters.GrowOpts{
 		Keypair:          d.Get("keypair").(string),
 		AvailabilityZone: d.Get(pathPrefix + "availability_zone").(string),
-		FlavorRef:        d.Get(pathPrefix + "flavor_id").(string),
+		FlavorRef:        flavorID,
 		Volume:           &instances.Volume{Size: &volumeSize, VolumeType: d.Get(pathPrefix + "volume_type").(string)},
 		ShardID:          shardID,
 	}
@@ -442,6 +971,12 @@ func databaseClusterActionGrow(updateCtx *dbResourceUpdateContext, shardID strin
 	}
 	updateCtx.StateConf.Target = []string{string(dbClusterStatusActive)}
 
+	// New instances may be added one by one, so wait for all of them to be
+	// active rather than for the cluster status only.
+	refresh := updateCtx.StateConf.Refresh
+	defer func() { updateCtx.StateConf.Refresh = refresh }()
+	updateCtx.StateConf.Refresh = databaseClusterInstanceCountStateRefreshFunc(updateCtx.Client, d.Id(), shardID, new.(int))
+
 	return databaseClusterActionGrowBase(updateCtx, growOpts, growSize)
 }
 
@@ -453,7 +988,9 @@ func databaseClusterActionGrowBase(updateCtx *dbResourceUpdateContext, growOpts
 	}
 	growClusterOpts := clusters.GrowClusterOpts{Grow: opts}
 
-	err := clusters.ClusterAction(updateCtx.Client, clusterID, &growClusterOpts).ExtractErr()
+	r := clusters.ClusterAction(updateCtx.Client, clusterID, &growClusterOpts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to grow cluster", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterActionGrow, err)
 	}
@@ -484,11 +1021,17 @@ func databaseClusterActionShrink(updateCtx *dbResourceUpdateContext, shardID str
 			errDBClusterActionShrinkWrongOptions)
 	}
 
-	cluster, err := clusters.Get(updateCtx.Client, d.Id()).Extract()
+	r := clusters.Get(updateCtx.Client, d.Id())
+	logDatabaseClusterRequest(updateCtx.Ctx, d.Id(), "Called Databases API to read cluster", r.Header)
+	cluster, err := r.Extract()
 	if err != nil {
 		return databaseClusterCheckDeleted(d, err)
 	}
 
+	if err := databaseClusterValidateShrinkOptions(shrinkOptions, cluster.Instances, shardID); err != nil {
+		return fmt.Errorf("%w: %s", errDBClusterActionShrinkWrongOptions, err)
+	}
+
 	ids, err := databaseClusterDetermineShrinkedInstances(shrinkSize, shrinkOptions, cluster.Instances, shardID)
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterActionShrinkInstancesExtract, err)
@@ -510,7 +1053,9 @@ func databaseClusterActionShrinkBase(updateCtx *dbResourceUpdateContext, shrinkO
 		Shrink: shrinkOpts,
 	}
 
-	err := clusters.ClusterAction(updateCtx.Client, clusterID, &shrinkClusterOpts).ExtractErr()
+	r := clusters.ClusterAction(updateCtx.Client, clusterID, &shrinkClusterOpts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to shrink cluster", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterActionShrink, err)
 	}
@@ -539,7 +1084,9 @@ func databaseClusterActionResizeVolume(updateCtx *dbResourceUpdateContext, shard
 
 func databaseClusterActionResizeVolumeBase(updateCtx *dbResourceUpdateContext, opts clusters.ResizeVolumeOpts) error {
 	clusterID := updateCtx.D.Id()
-	err := clusters.ClusterAction(updateCtx.Client, clusterID, &opts).ExtractErr()
+	r := clusters.ClusterAction(updateCtx.Client, clusterID, &opts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to resize volume", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterActionResizeVolume, err)
 	}
@@ -582,7 +1129,9 @@ func databaseClusterActionResizeWalVolume(updateCtx *dbResourceUpdateContext, sh
 
 func databaseClusterActionResizeWalVolumeBase(updateCtx *dbResourceUpdateContext, opts clusters.ResizeWalVolumeOpts) error {
 	clusterID := updateCtx.D.Id()
-	err := clusters.ClusterAction(updateCtx.Client, clusterID, &opts).ExtractErr()
+	r := clusters.ClusterAction(updateCtx.Client, clusterID, &opts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to resize wal volume", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterActionResizeWalVolume, err)
 	}
@@ -597,8 +1146,12 @@ func databaseClusterActionResizeFlavor(updateCtx *dbResourceUpdateContext, shard
 		return err
 	}
 
+	return databaseClusterActionResizeFlavorByID(updateCtx, shardID, d.Get(pathPrefix+"flavor_id").(string))
+}
+
+func databaseClusterActionResizeFlavorByID(updateCtx *dbResourceUpdateContext, shardID, flavorID string) error {
 	var resizeOpts clusters.ResizeOpts
-	resizeOpts.Resize.FlavorRef = d.Get(pathPrefix + "flavor_id").(string)
+	resizeOpts.Resize.FlavorRef = flavorID
 	resizeOpts.Resize.ShardID = shardID
 
 	updateCtx.StateConf.Pending = []string{string(dbClusterStatusResize)}
@@ -609,7 +1162,9 @@ func databaseClusterActionResizeFlavor(updateCtx *dbResourceUpdateContext, shard
 
 func databaseClusterActionResizeFlavorBase(updateCtx *dbResourceUpdateContext, opts clusters.ResizeOpts) error {
 	clusterID := updateCtx.D.Id()
-	err := clusters.ClusterAction(updateCtx.Client, clusterID, &opts).ExtractErr()
+	r := clusters.ClusterAction(updateCtx.Client, clusterID, &opts)
+	logDatabaseClusterRequest(updateCtx.Ctx, clusterID, "Called Databases API to resize flavor", r.Header)
+	err := r.ExtractErr()
 	if err != nil {
 		return fmt.Errorf("%w: %s", errDBClusterActionResizeFlavor, err)
 	}
@@ -638,6 +1193,24 @@ func databaseClusterActionEnableRoot(updateCtx *dbResourceUpdateContext) diag.Di
 	return nil
 }
 
+// databaseClusterActionEnableRootWithoutStoring enables root user with the
+// password from the configuration, so that no generated secret has to be
+// saved to the state or shown to the user.
+func databaseClusterActionEnableRootWithoutStoring(updateCtx *dbResourceUpdateContext) diag.Diagnostics {
+	clusterID := updateCtx.D.Id()
+	var rootUserEnableOpts instances.RootUserEnableOpts
+	rootUserEnableOpts.Password = updateCtx.D.Get("root_password").(string)
+	if rootUserEnableOpts.Password == "" {
+		return diag.Errorf("error creating root user for cluster: %s: root_password must be set when store_root_password is false", clusterID)
+	}
+	_, err := instances.RootUserEnable(updateCtx.Client, clusterID, &rootUserEnableOpts).Extract()
+	if err != nil {
+		return diag.Errorf("error creating root user for cluster: %s: %s", clusterID, err)
+	}
+	updateCtx.D.Set("root_enabled", true)
+	return nil
+}
+
 func getClusterStatus(c *clusters.ClusterResp) string {
 	instancesStatus := string(dbInstanceStatusActive)
 	for _, inst := range c.Instances {
@@ -701,3 +1274,79 @@ func databaseClusterStateRefreshFunc(client *gophercloud.ServiceClient, clusterI
 		return c, clusterStatus, nil
 	}
 }
+
+// databaseClusterInstanceCountStateRefreshFunc reports the cluster as growing
+// until the shard has count active instances. If shardID is empty, all
+// instances of the cluster are counted.
+func databaseClusterInstanceCountStateRefreshFunc(client *gophercloud.ServiceClient, clusterID string, shardID string, count int) retry.StateRefreshFunc {
+	refresh := databaseClusterStateRefreshFunc(client, clusterID, nil)
+	return func() (interface{}, string, error) {
+		c, status, err := refresh()
+		if err != nil || status != string(dbClusterStatusActive) {
+			return c, status, err
+		}
+
+		insts := c.(*clusters.ClusterResp).Instances
+		if shardID != "" {
+			insts = getDatabaseClusterShardInstances(insts)[shardID]
+		}
+		if countDatabaseClusterActiveInstances(insts) < count {
+			return c, string(dbClusterStatusGrow), nil
+		}
+		return c, status, nil
+	}
+}
+
+// databaseClusterConfigurationStateRefreshFunc reports the cluster as updating
+// until the configuration group with configurationID is attached to it.
+func databaseClusterConfigurationStateRefreshFunc(client *gophercloud.ServiceClient, clusterID string, configurationID string) retry.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		c, err := clusters.Get(client, clusterID).Extract()
+		if err != nil {
+			return nil, "", err
+		}
+
+		clusterStatus := getClusterStatus(c)
+		if clusterStatus == string(dbClusterStatusError) {
+			return c, clusterStatus, fmt.Errorf("there was an error attaching configuration to the database cluster")
+		}
+		if c.ConfigurationID != configurationID {
+			return c, string(dbClusterStatusUpdating), nil
+		}
+
+		return c, clusterStatus, nil
+	}
+}
+
+// databaseClusterBackupBeforeDelete creates a backup of the cluster and waits
+// for it to complete. It returns ID of the backup.
+func databaseClusterBackupBeforeDelete(ctx context.Context, client *gophercloud.ServiceClient, cluster *clusters.ClusterResp, timeout time.Duration) (string, error) {
+	opts := backups.Backup{
+		Backup: &backups.BackupCreateOpts{
+			Name:        fmt.Sprintf("%s-before-delete-%s", cluster.Name, time.Now().UTC().Format("20060102150405")),
+			Description: fmt.Sprintf("Backup of cluster %s made before its deletion", cluster.ID),
+			Cluster:     cluster.ID,
+		},
+	}
+
+	r := backups.Create(client, &opts)
+	logDatabaseClusterRequest(ctx, cluster.ID, "Called Databases API to create backup", r.Header)
+	backup, err := r.Extract()
+	if err != nil {
+		return "", fmt.Errorf("error creating backup: %s", err)
+	}
+
+	stateConf := &retry.StateChangeConf{
+		Pending:    []string{dbBackupStatusNew, dbBackupStatusBuild},
+		Target:     []string{dbBackupStatusActive},
+		Refresh:    backupStateRefreshFunc(client, backup.ID),
+		Timeout:    timeout,
+		Delay:      10 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+	if _, err := stateConf.WaitForStateContext(ctx); err != nil {
+		return backup.ID, fmt.Errorf("error waiting for backup %s to become ready: %s", backup.ID, err)
+	}
+
+	return backup.ID, nil
+}
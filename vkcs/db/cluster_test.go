@@ -0,0 +1,834 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	thclient "github.com/gophercloud/gophercloud/testhelper/client"
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/backups"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/clusters"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/instances"
+)
+
+func TestDatabaseClusterShardFlavorID(t *testing.T) {
+	shardInsts := []clusters.ClusterInstanceResp{
+		{ID: "inst0", Flavor: &instances.Links{ID: "flavor0"}},
+		{ID: "inst1", Flavor: nil},
+		{ID: "inst2", Flavor: &instances.Links{ID: "flavor1"}},
+	}
+
+	assert.Equal(t, "flavor0", databaseClusterShardFlavorID(shardInsts, ""))
+	assert.Equal(t, "flavor1", databaseClusterShardFlavorID(shardInsts, "flavor0"))
+	assert.Equal(t, "flavor0", databaseClusterShardFlavorID(shardInsts, "flavor1"))
+	assert.Equal(t, "flavor0", databaseClusterShardFlavorID(shardInsts[:2], "flavor0"))
+	assert.Equal(t, "", databaseClusterShardFlavorID(shardInsts[1:2], ""))
+}
+
+func TestFlattenDatabaseClusterShardInstanceCount(t *testing.T) {
+	shardInsts := []clusters.ClusterInstanceResp{
+		{ID: "inst0", Status: "ACTIVE", Flavor: &instances.Links{ID: "flavor0"}, Volume: &instances.Volume{}},
+		{ID: "inst1", Status: "BUILD", Flavor: &instances.Links{ID: "flavor0"}, Volume: &instances.Volume{}},
+	}
+
+	shard := flattenDatabaseClusterShard("shard0", shardInsts)
+
+	assert.Equal(t, 2, shard["size"])
+	assert.Equal(t, 1, shard["instance_count"])
+}
+
+func TestFlattenDatabaseClusterShardLeader(t *testing.T) {
+	shardInsts := []clusters.ClusterInstanceResp{
+		{ID: "inst0", Role: "replica", Flavor: &instances.Links{ID: "flavor0"}, Volume: &instances.Volume{}},
+		{ID: "inst1", Role: DBClusterInstanceRoleLeader, Flavor: &instances.Links{ID: "flavor0"}, Volume: &instances.Volume{}},
+	}
+
+	assert.Equal(t, "inst1", flattenDatabaseClusterShard("shard0", shardInsts)["leader"])
+	assert.Equal(t, "", flattenDatabaseClusterShard("shard0", shardInsts[:1])["leader"])
+}
+
+func TestDatabaseClusterDetermineShrinkedInstancesKeepsOtherShards(t *testing.T) {
+	clusterInsts := []clusters.ClusterInstanceResp{
+		{ID: "inst0", ShardID: "shard0"},
+		{ID: "inst1", ShardID: "shard0"},
+		{ID: "inst2", ShardID: "shard0"},
+		{ID: "inst3", ShardID: "shard1"},
+	}
+
+	ids, err := databaseClusterDetermineShrinkedInstances(1, []string{"inst0", "inst2"}, clusterInsts, "shard0")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []clusters.ShrinkOpts{{ID: "inst1"}}, ids)
+}
+
+func TestDatabaseClusterValidateShrinkOptions(t *testing.T) {
+	clusterInsts := []clusters.ClusterInstanceResp{
+		{ID: "inst0", ShardID: "shard0"},
+		{ID: "inst1", ShardID: "shard1"},
+	}
+
+	assert.NoError(t, databaseClusterValidateShrinkOptions([]string{"inst0"}, clusterInsts, "shard0"))
+	assert.EqualError(t, databaseClusterValidateShrinkOptions([]string{"inst1"}, clusterInsts, "shard0"),
+		"shard shard0 does not have instance: inst1")
+	assert.EqualError(t, databaseClusterValidateShrinkOptions([]string{"inst9"}, clusterInsts, "shard1"),
+		"shard shard1 does not have instance: inst9")
+}
+
+func TestDatabaseClusterStateRefreshFuncWaitsForCapabilities(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/clusters/cluster1", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"cluster": {
+				"id": "cluster1",
+				"task": {"name": "NONE"},
+				"instances": [
+					{"id": "inst1", "status": "ACTIVE"},
+					{"id": "inst2", "status": "ACTIVE"}
+				]
+			}
+		}`)
+	})
+
+	// The capability is already applied on the first instance, while the second
+	// one reports it as being applied for the first two requests.
+	th.Mux.HandleFunc("/instances/inst1/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"capabilities": [{"name": "node_exporter", "status": "ACTIVE"}]}`)
+	})
+	inst2Calls := 0
+	th.Mux.HandleFunc("/instances/inst2/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		inst2Calls++
+		status := "ACTIVE"
+		if inst2Calls <= 2 {
+			status = "APPLYING"
+		}
+		fmt.Fprintf(w, `{"capabilities": [{"name": "node_exporter", "status": "%s"}]}`, status)
+	})
+
+	capabilities := []instances.CapabilityOpts{{Name: "node_exporter"}}
+	refresh := databaseClusterStateRefreshFunc(thclient.ServiceClient(), "cluster1", &capabilities)
+
+	for i := 0; i < 2; i++ {
+		_, status, err := refresh()
+		assert.NoError(t, err)
+		assert.Equal(t, string(dbClusterStatusBuild), status)
+	}
+
+	_, status, err := refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, string(dbClusterStatusActive), status)
+}
+
+func TestDatabaseClusterStateRefreshFuncCapabilityError(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/clusters/cluster1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"cluster": {"id": "cluster1", "task": {"name": "NONE"}, "instances": [{"id": "inst1", "status": "ACTIVE"}]}}`)
+	})
+	th.Mux.HandleFunc("/instances/inst1/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"capabilities": [{"name": "node_exporter", "status": "ERROR"}]}`)
+	})
+
+	capabilities := []instances.CapabilityOpts{{Name: "node_exporter"}}
+	refresh := databaseClusterStateRefreshFunc(thclient.ServiceClient(), "cluster1", &capabilities)
+
+	_, _, err := refresh()
+	assert.Error(t, err)
+}
+
+func TestCheckDatabaseClusterInstanceCount(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/clusters/cluster1", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"cluster": {"id": "cluster1", "task": {"name": "NONE"}, "instances": [
+			{"id": "inst0", "status": "ACTIVE", "shard_id": "shard0"},
+			{"id": "inst1", "status": "ACTIVE", "shard_id": "shard0"},
+			{"id": "inst2", "status": "ACTIVE", "shard_id": "shard1"}
+		]}}`)
+	})
+
+	assert.NoError(t, checkDatabaseClusterInstanceCount(thclient.ServiceClient(), "cluster1", 3))
+	assert.EqualError(t, checkDatabaseClusterInstanceCount(thclient.ServiceClient(), "cluster1", 4),
+		"cluster cluster1 has 3 instances, but 4 were requested (instances by shard: shard0: 2, shard1: 1)")
+}
+
+func TestDatabaseClusterConfigurationStateRefreshFunc(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	var clusterCalls int
+	th.Mux.HandleFunc("/clusters/cluster1", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		clusterCalls++
+		configurationID := ""
+		if clusterCalls > 1 {
+			configurationID = "config1"
+		}
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"cluster": {"id": "cluster1", "configuration_id": %q, "task": {"name": "NONE"}, "instances": [{"id": "inst1", "status": "ACTIVE"}]}}`, configurationID)
+	})
+
+	refresh := databaseClusterConfigurationStateRefreshFunc(thclient.ServiceClient(), "cluster1", "config1")
+
+	_, status, err := refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, string(dbClusterStatusUpdating), status)
+
+	_, status, err = refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, string(dbClusterStatusActive), status)
+}
+
+func TestDatabaseClusterShardDatastoreVersion(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	for id, version := range map[string]string{"inst0": "20.8", "inst1": "23.3"} {
+		body := fmt.Sprintf(`{"instance": {"id": "%s", "datastore": {"type": "clickhouse", "version": "%s"}}}`, id, version)
+		th.Mux.HandleFunc("/instances/"+id, func(w http.ResponseWriter, r *http.Request) {
+			th.TestMethod(t, r, "GET")
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, body)
+		})
+	}
+
+	shardInsts := []clusters.ClusterInstanceResp{{ID: "inst0"}, {ID: "inst1"}}
+
+	details, err := getDatabaseClusterShardInstanceDetails(thclient.ServiceClient(), shardInsts)
+	assert.NoError(t, err)
+	assert.Equal(t, "23.3", databaseClusterShardDatastoreVersion(details, "20.8"))
+
+	details, err = getDatabaseClusterShardInstanceDetails(thclient.ServiceClient(), shardInsts[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, "20.8", databaseClusterShardDatastoreVersion(details, "20.8"))
+
+	_, err = getDatabaseClusterShardInstanceDetails(thclient.ServiceClient(), []clusters.ClusterInstanceResp{{ID: "inst2"}})
+	assert.Error(t, err)
+	assert.Equal(t, "20.8", databaseClusterShardDatastoreVersion(nil, "20.8"))
+}
+
+func TestDatabaseClusterShardUpdatedAt(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	for id, updated := range map[string]string{"inst0": "2023-01-02T10:00:00", "inst1": "2023-01-01T10:00:00"} {
+		body := fmt.Sprintf(`{"instance": {"id": "%s", "updated": "%s"}}`, id, updated)
+		th.Mux.HandleFunc("/instances/"+id, func(w http.ResponseWriter, r *http.Request) {
+			th.TestMethod(t, r, "GET")
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, body)
+		})
+	}
+
+	details, err := getDatabaseClusterShardInstanceDetails(thclient.ServiceClient(), []clusters.ClusterInstanceResp{{ID: "inst0"}, {ID: "inst1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-01-02T10:00:00Z", databaseClusterShardUpdatedAt(details))
+	assert.Equal(t, "", databaseClusterShardUpdatedAt(nil))
+}
+
+func TestDatabaseClusterOrphanInstanceIDs(t *testing.T) {
+	shardsInstances := getDatabaseClusterShardInstances([]clusters.ClusterInstanceResp{
+		{ID: "inst2", ShardID: ""},
+		{ID: "inst0", ShardID: "shard0"},
+		{ID: "inst1", ShardID: ""},
+	})
+
+	assert.Equal(t, []string{"inst1", "inst2"}, databaseClusterOrphanInstanceIDs(shardsInstances))
+	delete(shardsInstances, "")
+	assert.Empty(t, databaseClusterOrphanInstanceIDs(shardsInstances))
+}
+
+func TestFlattenDatabaseClusterBackups(t *testing.T) {
+	allBackups := []backups.BackupResp{
+		{ID: "backup0", ClusterID: "cluster1", Created: "2023-01-01T00:00:00", Size: 0.5},
+		{ID: "backup1", InstanceID: "inst1", Created: "2023-01-02T00:00:00", Size: 1},
+		{ID: "backup2", ClusterID: "cluster2", Created: "2023-01-03T00:00:00", Size: 2},
+	}
+
+	assert.Equal(t, []map[string]interface{}{
+		{"id": "backup0", "created": "2023-01-01T00:00:00", "size": 0.5},
+	}, flattenDatabaseClusterBackups(allBackups, "cluster1"))
+	assert.Empty(t, flattenDatabaseClusterBackups(allBackups, "cluster3"))
+}
+
+func TestGetDatabaseClusterBackups(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/backups", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestFormValues(t, r, map[string]string{"cluster_id": "cluster1"})
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"backups": [
+			{"id": "backup0", "cluster_id": "cluster1", "created": "2023-01-01T00:00:00", "size": 0.5},
+			{"id": "backup1", "cluster_id": "cluster2", "created": "2023-01-02T00:00:00", "size": 1}
+		]}`)
+	})
+
+	clusterBackups, err := getDatabaseClusterBackups(thclient.ServiceClient(), "cluster1")
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"id": "backup0", "created": "2023-01-01T00:00:00", "size": 0.5},
+	}, clusterBackups)
+}
+
+func TestDatabaseClusterBackupScheduleUnsupported(t *testing.T) {
+	assert.True(t, databaseClusterBackupScheduleUnsupported(gophercloud.ErrDefault404{}))
+	assert.True(t, databaseClusterBackupScheduleUnsupported(gophercloud.ErrDefault405{}))
+	assert.True(t, databaseClusterBackupScheduleUnsupported(gophercloud.ErrUnexpectedResponseCode{Actual: http.StatusNotImplemented}))
+	assert.False(t, databaseClusterBackupScheduleUnsupported(gophercloud.ErrDefault500{}))
+	assert.False(t, databaseClusterBackupScheduleUnsupported(nil))
+}
+
+func TestGetDatabaseClusterInstancePort(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/ports", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		switch r.URL.Query().Get("device_id") {
+		case "server1":
+			fmt.Fprint(w, `{"ports": [{"id": "port1", "mac_address": "fa:16:3e:00:00:01", "device_id": "server1", "fixed_ips": [{"ip_address": "10.0.0.11"}]}]}`)
+		case "server2":
+			fmt.Fprint(w, `{"ports": [
+				{"id": "port2", "mac_address": "fa:16:3e:00:00:02", "device_id": "server2", "fixed_ips": [{"ip_address": "10.0.0.12"}]},
+				{"id": "port3", "mac_address": "fa:16:3e:00:00:03", "device_id": "server2", "fixed_ips": [{"ip_address": "10.0.1.12"}]}
+			]}`)
+		default:
+			fmt.Fprint(w, `{"ports": []}`)
+		}
+	})
+
+	tableTest := []struct {
+		computeInstanceID string
+		ips               []string
+		portID            string
+		err               bool
+	}{
+		{computeInstanceID: "server1", ips: []string{"10.0.0.11"}, portID: "port1"},
+		{computeInstanceID: "server1", portID: "port1"},
+		{computeInstanceID: "server1", ips: []string{"10.0.0.12"}},
+		{computeInstanceID: "server2", ips: []string{"10.0.1.12"}, portID: "port3"},
+		{computeInstanceID: "server2", ips: []string{"10.0.0.12", "10.0.1.12"}, err: true},
+		{computeInstanceID: "server2", err: true},
+		{computeInstanceID: "server3", ips: []string{"10.0.0.13"}},
+		{computeInstanceID: ""},
+	}
+
+	for _, test := range tableTest {
+		port, err := getDatabaseClusterInstancePort(thclient.ServiceClient(), test.computeInstanceID, test.ips)
+		if test.err {
+			assert.Error(t, err, test.computeInstanceID)
+			continue
+		}
+		assert.NoError(t, err, test.computeInstanceID)
+		if test.portID == "" {
+			assert.Nil(t, port, test.computeInstanceID)
+			continue
+		}
+		assert.Equal(t, test.portID, port.ID, test.computeInstanceID)
+	}
+}
+
+func TestGetDatabaseClusterPortFloatingIP(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		if r.URL.Query().Get("port_id") != "port1" {
+			fmt.Fprint(w, `{"floatingips": []}`)
+			return
+		}
+		fmt.Fprint(w, `{"floatingips": [{"id": "fip1", "floating_ip_address": "203.0.113.10", "port_id": "port1"}]}`)
+	})
+
+	ip, err := getDatabaseClusterPortFloatingIP(thclient.ServiceClient(), "port1")
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.10", ip)
+
+	ip, err = getDatabaseClusterPortFloatingIP(thclient.ServiceClient(), "port2")
+	assert.NoError(t, err)
+	assert.Empty(t, ip)
+}
+
+func TestDatabaseClusterWaitForPendingOperation(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	calls := 0
+	th.Mux.HandleFunc("/clusters/cluster1", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		calls++
+		task := "NONE"
+		if calls < 3 {
+			task = "GROWING_CLUSTER"
+		}
+		fmt.Fprintf(w, `{"cluster": {"id": "cluster1", "task": {"name": "%s"}, "instances": [{"id": "inst1", "status": "ACTIVE"}]}}`, task)
+	})
+
+	d := schema.TestResourceDataRaw(t, ResourceDatabaseClusterWithShards().Schema, map[string]interface{}{})
+	d.SetId("cluster1")
+	updateCtx := &dbResourceUpdateContext{
+		Ctx:    context.Background(),
+		Client: thclient.ServiceClient(),
+		D:      d,
+		StateConf: &retry.StateChangeConf{
+			Refresh:      databaseClusterStateRefreshFunc(thclient.ServiceClient(), "cluster1", nil),
+			Timeout:      time.Minute,
+			PollInterval: time.Millisecond,
+		},
+	}
+
+	assert.NoError(t, databaseClusterWaitForPendingOperation(updateCtx))
+	assert.Equal(t, 3, calls)
+
+	assert.NoError(t, databaseClusterWaitForPendingOperation(updateCtx))
+	assert.Equal(t, 4, calls)
+}
+
+func TestDatabaseClusterInstanceCountStateRefreshFunc(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	// Shard0 is grown from one instance by two, new instances become active
+	// one by one while the cluster task is already finished.
+	calls := 0
+	th.Mux.HandleFunc("/clusters/cluster1", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		calls++
+		insts := []string{
+			`{"id": "inst0", "status": "ACTIVE", "shard_id": "shard0"}`,
+			`{"id": "inst3", "status": "ACTIVE", "shard_id": "shard1"}`,
+		}
+		for i := 1; i < calls && i <= 2; i++ {
+			insts = append(insts, fmt.Sprintf(`{"id": "inst%d", "status": "ACTIVE", "shard_id": "shard0"}`, i))
+		}
+		fmt.Fprintf(w, `{"cluster": {"id": "cluster1", "task": {"name": "NONE"}, "instances": [%s]}}`, strings.Join(insts, ","))
+	})
+
+	refresh := databaseClusterInstanceCountStateRefreshFunc(thclient.ServiceClient(), "cluster1", "shard0", 3)
+
+	for i := 0; i < 2; i++ {
+		_, status, err := refresh()
+		assert.NoError(t, err)
+		assert.Equal(t, string(dbClusterStatusGrow), status)
+	}
+	_, status, err := refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, string(dbClusterStatusActive), status)
+
+	refresh = databaseClusterInstanceCountStateRefreshFunc(thclient.ServiceClient(), "cluster1", "", 4)
+	_, status, err = refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, string(dbClusterStatusActive), status)
+}
+
+func TestDatabaseClusterActionGrowWithFlavorID(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/clusters/cluster1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		if r.Method == "POST" {
+			th.TestJSONRequest(t, r, `{"grow": [{"availability_zone": "GZ1", "flavorRef": "flavor1", "key_name": "",
+				"shard_id": "shard0", "volume": {"size": 10, "type": "ceph-ssd"}}]}`)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		fmt.Fprint(w, `{"cluster": {"id": "cluster1", "task": {"name": "NONE"}, "instances": [
+			{"id": "inst0", "status": "ACTIVE", "shard_id": "shard0"}
+		]}}`)
+	})
+
+	d := schema.TestResourceDataRaw(t, ResourceDatabaseClusterWithShards().Schema, map[string]interface{}{
+		"shard": []interface{}{map[string]interface{}{
+			"shard_id": "shard0", "size": 1, "flavor_id": "flavor0", "volume_size": 10, "volume_type": "ceph-ssd", "availability_zone": "GZ1",
+		}},
+	})
+	d.SetId("cluster1")
+	updateCtx := &dbResourceUpdateContext{
+		Ctx:       context.Background(),
+		Client:    thclient.ServiceClient(),
+		D:         d,
+		StateConf: &retry.StateChangeConf{Timeout: time.Minute, PollInterval: time.Millisecond},
+	}
+
+	assert.NoError(t, databaseClusterActionGrowWithFlavorID(updateCtx, "shard0", "flavor1"))
+}
+
+func TestDatabaseClusterShardUpdateContext(t *testing.T) {
+	updateCtx := &dbResourceUpdateContext{
+		Ctx:       context.Background(),
+		StateConf: &retry.StateChangeConf{Timeout: 30 * time.Minute},
+	}
+
+	assert.Same(t, updateCtx, databaseClusterShardUpdateContext(updateCtx, map[string]interface{}{"operation_timeout": ""}))
+
+	shardUpdateCtx := databaseClusterShardUpdateContext(updateCtx, map[string]interface{}{"operation_timeout": "2h"})
+	assert.Equal(t, 2*time.Hour, shardUpdateCtx.StateConf.Timeout)
+	assert.Equal(t, 30*time.Minute, updateCtx.StateConf.Timeout)
+
+	shardUpdateCtx.StateConf.Pending = []string{string(dbClusterStatusGrow)}
+	assert.Empty(t, updateCtx.StateConf.Pending)
+}
+
+func TestDatabaseClusterCreateProcessError(t *testing.T) {
+	tableTest := []struct {
+		name    string
+		err     error
+		summary string
+		detail  string
+	}{
+		{
+			name: "quota exceeded",
+			err: gophercloud.ErrUnexpectedResponseCode{
+				Method: "POST",
+				URL:    "/clusters",
+				Actual: http.StatusRequestEntityTooLarge,
+				Body:   []byte(`{"overLimit": {"code": 413, "message": "Quota exceeded for resources: ['instances', 'volumes']."}}`),
+			},
+			summary: "Quota exceeded creating vkcs_db_cluster_with_shards",
+			detail:  "Quota exceeded for resources: ['instances', 'volumes'].",
+		},
+		{
+			name: "quota exceeded without message",
+			err: gophercloud.ErrUnexpectedResponseCode{
+				Method: "POST",
+				URL:    "/clusters",
+				Actual: http.StatusRequestEntityTooLarge,
+			},
+			summary: "Quota exceeded creating vkcs_db_cluster_with_shards",
+			detail:  "The project does not have enough quota to create the cluster.",
+		},
+		{
+			name: "forbidden",
+			err: gophercloud.ErrDefault403{ErrUnexpectedResponseCode: gophercloud.ErrUnexpectedResponseCode{
+				Method: "POST",
+				URL:    "/clusters",
+				Actual: http.StatusForbidden,
+				Body:   []byte(`{"forbidden": {"message": "Quota exceeded for floating_ips"}}`),
+			}},
+			summary: "error creating vkcs_db_cluster_with_shards: ",
+		},
+	}
+
+	for _, test := range tableTest {
+		diags := databaseClusterCreateProcessError(test.err, "vkcs_db_cluster_with_shards")
+		assert.Len(t, diags, 1, test.name)
+		assert.True(t, strings.HasPrefix(diags[0].Summary, test.summary), test.name)
+		assert.Contains(t, diags[0].Detail, test.detail, test.name)
+	}
+}
+
+func TestFlattenDatabaseClusterEffectiveCapabilities(t *testing.T) {
+	capabilities := flattenDatabaseClusterEffectiveCapabilities([]instances.DatabaseCapability{
+		{Name: "node_exporter", Params: map[string]string{"listen_port": "9100"}, Status: "ACTIVE"},
+		{Name: "jmx_exporter", Status: "APPLYING"},
+	})
+
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "node_exporter", "settings": map[string]string{"listen_port": "9100"}, "status": "ACTIVE"},
+		{"name": "jmx_exporter", "settings": map[string]string(nil), "status": "APPLYING"},
+	}, capabilities)
+}
+
+func TestGetDatabaseClusterWriteEndpoint(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/lbaas/loadbalancers/lb1", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"loadbalancer": {"id": "lb1", "vip_address": "10.0.0.10"}}`)
+	})
+
+	endpoint, err := getDatabaseClusterWriteEndpoint(thclient.ServiceClient(), "lb1")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.10", endpoint)
+
+	_, err = getDatabaseClusterWriteEndpoint(thclient.ServiceClient(), "lb2")
+	assert.Error(t, err)
+}
+
+func TestGetDatabaseClusterShardFlavorID(t *testing.T) {
+	flavors := map[string]string{"Standard-4-8": "flavor1"}
+
+	assert.Equal(t, "flavor1", getDatabaseClusterShardFlavorID(map[string]interface{}{"flavor_id": "", "flavor_name": "Standard-4-8"}, flavors, "flavor0"))
+	assert.Equal(t, "flavor2", getDatabaseClusterShardFlavorID(map[string]interface{}{"flavor_id": "flavor2", "flavor_name": ""}, flavors, "flavor0"))
+	assert.Equal(t, "flavor0", getDatabaseClusterShardFlavorID(map[string]interface{}{"flavor_id": "", "flavor_name": ""}, flavors, "flavor0"))
+}
+
+func TestDatabaseClusterEffectiveKeypair(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	keypairs := map[string]string{"server0": "keypair0", "server1": "keypair1"}
+	for id, keypair := range keypairs {
+		id, keypair := id, keypair
+		th.Mux.HandleFunc("/servers/"+id, func(w http.ResponseWriter, r *http.Request) {
+			th.TestMethod(t, r, "GET")
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"server": {"id": "%s", "key_name": "%s"}}`, id, keypair)
+		})
+	}
+
+	insts := []clusters.ClusterInstanceResp{
+		{ID: "inst0", СomputeInstanceID: "server0"},
+		{ID: "inst1", СomputeInstanceID: ""},
+		{ID: "inst2", СomputeInstanceID: "server1"},
+	}
+
+	keypair, err := databaseClusterEffectiveKeypair(thclient.ServiceClient(), insts, "keypair0")
+	assert.NoError(t, err)
+	assert.Equal(t, "keypair1", keypair)
+
+	keypair, err = databaseClusterEffectiveKeypair(thclient.ServiceClient(), insts[:2], "keypair0")
+	assert.NoError(t, err)
+	assert.Equal(t, "keypair0", keypair)
+}
+
+func testDatabaseClusterWithShardsConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"name":      "cluster",
+		"datastore": []interface{}{map[string]interface{}{"type": "clickhouse", "version": "20.8"}},
+		"shard": []interface{}{map[string]interface{}{
+			"shard_id":    "shard0",
+			"size":        1,
+			"flavor_id":   "flavor0",
+			"volume_size": 10,
+			"volume_type": "ceph-ssd",
+		}},
+	}
+}
+
+// testDatabaseClusterWithShardsDiff plans the configuration over the cluster
+// with the state attributes, or over no cluster if attributes are nil.
+func testDatabaseClusterWithShardsDiff(attributes map[string]string, raw map[string]interface{}) error {
+	r := ResourceDatabaseClusterWithShards()
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	rawConfig, err := ctyjson.Unmarshal(b, r.CoreConfigSchema().ImpliedType())
+	if err != nil {
+		return err
+	}
+	state := &terraform.InstanceState{RawConfig: rawConfig}
+	if attributes != nil {
+		state.ID = attributes["id"]
+		state.Attributes = attributes
+	}
+	_, err = r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil)
+	return err
+}
+
+func TestResourceDatabaseClusterWithShardsPlanRootPassword(t *testing.T) {
+	tableTest := []struct {
+		storeRootPassword interface{}
+		rootPassword      interface{}
+		err               string
+	}{
+		{
+			storeRootPassword: nil,
+			rootPassword:      nil,
+		},
+		{
+			storeRootPassword: false,
+			rootPassword:      "secret",
+		},
+		{
+			storeRootPassword: false,
+			rootPassword:      nil,
+			err:               "root_password must be set to enable root when store_root_password is false",
+		},
+	}
+
+	for _, test := range tableTest {
+		raw := testDatabaseClusterWithShardsConfig()
+		raw["root_enabled"] = true
+		if test.storeRootPassword != nil {
+			raw["store_root_password"] = test.storeRootPassword
+		}
+		if test.rootPassword != nil {
+			raw["root_password"] = test.rootPassword
+		}
+
+		err := testDatabaseClusterWithShardsDiff(nil, raw)
+		if test.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestDatabaseClusterShardStateFlavorID(t *testing.T) {
+	tableTest := []struct {
+		shard    map[string]interface{}
+		flavorID string
+		expected string
+	}{
+		{
+			shard:    map[string]interface{}{"flavor_id": "flavor1", "flavor_name": ""},
+			flavorID: "flavor2",
+			expected: "flavor2",
+		},
+		{
+			shard:    map[string]interface{}{"flavor_id": "", "flavor_name": "Standard-2-8-50"},
+			flavorID: "flavor2",
+			expected: "",
+		},
+		{
+			shard:    map[string]interface{}{"flavor_id": "", "flavor_name": ""},
+			flavorID: "flavor0",
+			expected: "",
+		},
+		{
+			shard:    map[string]interface{}{"flavor_id": "", "flavor_name": ""},
+			flavorID: "flavor2",
+			expected: "flavor2",
+		},
+	}
+
+	for _, test := range tableTest {
+		assert.Equal(t, test.expected, databaseClusterShardStateFlavorID(test.shard, test.flavorID, "flavor0"))
+	}
+}
+func TestResourceDatabaseClusterWithShardsPlanBackupSchedule(t *testing.T) {
+	attributes := map[string]string{
+		"id":                               "cluster1",
+		"name":                             "cluster",
+		"datastore.#":                      "1",
+		"datastore.0.type":                 "clickhouse",
+		"datastore.0.version":              "20.8",
+		"backup_schedule.#":                "1",
+		"backup_schedule.0.name":           "schedule",
+		"backup_schedule.0.start_hours":    "16",
+		"backup_schedule.0.start_minutes":  "20",
+		"backup_schedule.0.interval_hours": "24",
+		"backup_schedule.0.keep_count":     "3",
+	}
+	backupSchedule := []interface{}{map[string]interface{}{
+		"name": "schedule", "start_hours": 16, "start_minutes": 20, "interval_hours": 12, "keep_count": 3,
+	}}
+
+	tableTest := []struct {
+		attributes     map[string]string
+		backupSchedule []interface{}
+		err            string
+	}{
+		{
+			attributes:     nil,
+			backupSchedule: backupSchedule,
+		},
+		{
+			attributes:     attributes,
+			backupSchedule: backupSchedule,
+		},
+		{
+			attributes:     attributes,
+			backupSchedule: nil,
+			err:            "backup_schedule of vkcs_db_cluster_with_shards cluster1 can't be removed, since Databases API does not support removing the schedule",
+		},
+	}
+
+	for _, test := range tableTest {
+		raw := testDatabaseClusterWithShardsConfig()
+		if test.backupSchedule != nil {
+			raw["backup_schedule"] = test.backupSchedule
+		}
+
+		err := testDatabaseClusterWithShardsDiff(test.attributes, raw)
+		if test.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestResourceDatabaseClusterWithShardsPlanVersionReplacement(t *testing.T) {
+	attributes := map[string]string{
+		"id":                  "cluster1",
+		"name":                "cluster",
+		"datastore.#":         "1",
+		"datastore.0.type":    "clickhouse",
+		"datastore.0.version": "20.8",
+	}
+
+	tableTest := []struct {
+		version                 string
+		allowVersionReplacement bool
+		err                     string
+	}{
+		{
+			version: "20.8",
+		},
+		{
+			version: "23.3",
+			err: "changing datastore version of vkcs_db_cluster_with_shards cluster1 from 20.8 to 23.3 destroys the cluster together with its data: " +
+				"create a backup and restore it to a new cluster to keep the data, or set allow_version_replacement to true",
+		},
+		{
+			version:                 "23.3",
+			allowVersionReplacement: true,
+		},
+	}
+
+	for _, test := range tableTest {
+		raw := testDatabaseClusterWithShardsConfig()
+		raw["datastore"] = []interface{}{map[string]interface{}{"type": "clickhouse", "version": test.version}}
+		raw["allow_version_replacement"] = test.allowVersionReplacement
+
+		err := testDatabaseClusterWithShardsDiff(attributes, raw)
+		if test.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestDatabaseClusterRevertFailedShards(t *testing.T) {
+	oldShards := []interface{}{
+		map[string]interface{}{"shard_id": "shard0", "size": 1, "volume_size": 10},
+		map[string]interface{}{"shard_id": "shard1", "size": 1, "volume_size": 10},
+	}
+	newShards := []interface{}{
+		map[string]interface{}{"shard_id": "shard1", "size": 2, "volume_size": 20},
+		map[string]interface{}{"shard_id": "shard0", "size": 2, "volume_size": 20},
+	}
+
+	assert.Equal(t, []interface{}{newShards[0], oldShards[0]},
+		databaseClusterRevertFailedShards(oldShards, newShards, map[string]bool{"shard0": true}))
+	assert.Equal(t, newShards, databaseClusterRevertFailedShards(oldShards, newShards, nil))
+}
@@ -3,8 +3,10 @@ package db
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/gophercloud/gophercloud"
+	configgroups "github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/config_groups"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/datastores"
 )
 
@@ -73,3 +75,37 @@ func retrieveDatabaseConfigGroupValues(client *gophercloud.ServiceClient, datast
 	}
 	return values, nil
 }
+
+// getDatabaseConfigGroupIDByName looks up a configuration group by its name
+// among the groups created for the given datastore.
+func getDatabaseConfigGroupIDByName(client *gophercloud.ServiceClient, name string, datastore datastores.DatastoreShort) (string, error) {
+	allPages, err := configgroups.List(client).AllPages()
+	if err != nil {
+		return "", fmt.Errorf("error retrieving vkcs_db_config_group list: %s", err)
+	}
+	allConfigGroups, err := configgroups.ExtractConfigGroups(allPages)
+	if err != nil {
+		return "", fmt.Errorf("error extracting vkcs_db_config_group list: %s", err)
+	}
+
+	var ids []string
+	for _, cg := range allConfigGroups {
+		if cg.Name != name {
+			continue
+		}
+		if !strings.EqualFold(cg.DatastoreName, datastore.Type) || cg.DatastoreVersionName != datastore.Version {
+			continue
+		}
+		ids = append(ids, cg.ID)
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("vkcs_db_config_group %q for datastore %s %s not found", name, datastore.Type, datastore.Version)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("found multiple vkcs_db_config_group named %q for datastore %s %s: %s",
+			name, datastore.Type, datastore.Version, strings.Join(ids, ", "))
+	}
+}
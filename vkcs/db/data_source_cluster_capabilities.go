@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/clients"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/clusters"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
+)
+
+func DataSourceDatabaseClusterCapabilities() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDatabaseClusterCapabilitiesRead,
+		Schema: map[string]*schema.Schema{
+			"cluster_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The id of the cluster.",
+			},
+
+			"region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The region in which to obtain the Databases client. If omitted, the `region` argument of the provider is used.",
+			},
+
+			"capabilities": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the capability.",
+						},
+						"settings": {
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Map of key-value settings of the capability.",
+						},
+					},
+				},
+				Description: "Capabilities applied to the cluster.",
+			},
+		},
+		Description: "Use this data source to get capabilities applied to a db cluster.",
+	}
+}
+
+func dataSourceDatabaseClusterCapabilitiesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(clients.Config)
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
+	if err != nil {
+		return diag.Errorf("error creating VKCS database client: %s", err)
+	}
+
+	clusterID := d.Get("cluster_id").(string)
+	r := clusters.GetCapabilities(DatabaseV1Client, clusterID)
+	logDatabaseClusterRequest(ctx, clusterID, "Called Databases API to read cluster capabilities", r.Header)
+	capabilities, err := r.Extract()
+	if err != nil {
+		return diag.Errorf("error retrieving capabilities of cluster %s: %s", clusterID, err)
+	}
+
+	d.SetId(clusterID)
+	d.Set("region", region)
+	d.Set("capabilities", flattenDatabaseInstanceCapabilities(capabilities))
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/acctest"
+)
+
+func TestAccDatabaseDataSourceClusterCapabilities_big(t *testing.T) {
+	resourceName := "vkcs_db_cluster_with_shards.basic"
+	datasourceName := "data.vkcs_db_cluster_capabilities.basic"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		CheckDestroy:      testAccCheckDatabaseClusterWithShardsDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: acctest.AccTestRenderConfig(testAccDataSourceDatabaseClusterCapabilitiesBasic, map[string]string{"TestAccDatabaseClusterWithShardsBasic": acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsBasic)}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(datasourceName, "id", resourceName, "id"),
+					resource.TestCheckResourceAttrPair(datasourceName, "capabilities.#", resourceName, "capabilities.#"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceDatabaseClusterCapabilitiesBasic = `
+{{.TestAccDatabaseClusterWithShardsBasic}}
+
+data "vkcs_db_cluster_capabilities" "basic" {
+	cluster_id = vkcs_db_cluster_with_shards.basic.id
+}
+`
@@ -175,7 +175,11 @@ func DataSourceDatabaseInstance() *schema.Resource {
 
 func dataSourceDatabaseInstanceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("error creating VKCS database client: %s", err)
 	}
@@ -190,7 +194,7 @@ func dataSourceDatabaseInstanceRead(ctx context.Context, d *schema.ResourceData,
 	d.Set("name", instance.Name)
 	d.Set("flavor_id", instance.Flavor.ID)
 	d.Set("datastore", flattenDatabaseInstanceDatastore(*instance.DataStore))
-	d.Set("region", util.GetRegion(d, config))
+	d.Set("region", region)
 	d.Set("ip", instance.IP)
 	d.Set("status", instance.Status)
 
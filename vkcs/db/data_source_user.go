@@ -62,7 +62,11 @@ func DataSourceDatabaseUser() *schema.Resource {
 
 func dataSourceDatabaseUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("error creating vkcs database client: %s", err)
 	}
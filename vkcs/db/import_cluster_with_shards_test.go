@@ -28,3 +28,54 @@ func TestAccDatabaseClusterWithShards_importBasic_big(t *testing.T) {
 		},
 	})
 }
+
+func TestAccDatabaseClusterWithShards_importRootEnabled_big(t *testing.T) {
+	resourceName := "vkcs_db_cluster_with_shards.root_enabled"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		CheckDestroy:      testAccCheckDatabaseClusterWithShardsDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsRootEnabled),
+			},
+
+			{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"root_password", "shard.0.volume_type", "shard.0.availability_zone", "shard.0.network", "shard.0.shard_id", "shard.0.size"},
+			},
+		},
+	})
+}
+
+const testAccDatabaseClusterWithShardsRootEnabled = `
+{{.BaseNetwork}}
+{{.BaseFlavor}}
+
+resource "vkcs_db_cluster_with_shards" "root_enabled" {
+  name         = "root-enabled"
+  root_enabled = true
+
+  datastore {
+    version = "20.8"
+    type    = "clickhouse"
+  }
+
+  shard {
+    size        = 1
+    shard_id    = "shard0"
+    flavor_id   = data.vkcs_compute_flavor.base.id
+    volume_size = 8
+    volume_type = "ceph-ssd"
+    network {
+      uuid = vkcs_networking_network.base.id
+    }
+    availability_zone = "{{.AvailabilityZone}}"
+  }
+
+  depends_on = [vkcs_networking_router_interface.base]
+}
+`
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/gophercloud/gophercloud"
 	"github.com/mitchellh/mapstructure"
@@ -37,6 +38,23 @@ func getClusterWithShardsDatastores() []string {
 	return []string{Clickhouse}
 }
 
+// validateClusterWithShardsDatastoreType accepts datastore types supported
+// by cluster with shards in any letter case.
+func validateClusterWithShardsDatastoreType(v interface{}, k string) ([]string, []error) {
+	value, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	supported := getClusterWithShardsDatastores()
+	for _, datastore := range supported {
+		if strings.EqualFold(value, datastore) {
+			return nil, nil
+		}
+	}
+	return nil, []error{fmt.Errorf("%s %q is not supported by cluster with shards, supported types are: %s",
+		k, value, strings.Join(supported, ", "))}
+}
+
 func getReplicaDatastores() []string {
 	return []string{PostgresProEnterprise, MySQL, Postgres, PostgresProEnterprise1C}
 }
@@ -48,7 +48,11 @@ func ResourceDatabaseCluster() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
 				config := meta.(clients.Config)
-				DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+				region, err := util.RequireRegion(d, config)
+				if err != nil {
+					return nil, err
+				}
+				DatabaseV1Client, err := config.DatabaseV1Client(region)
 				if err != nil {
 					return nil, fmt.Errorf("error creating VKCS database client: %s", err)
 				}
@@ -463,7 +467,11 @@ func ResourceDatabaseCluster() *schema.Resource {
 
 func resourceDatabaseClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
@@ -578,7 +586,7 @@ func resourceDatabaseClusterCreate(ctx context.Context, d *schema.ResourceData,
 
 	cluster, err := clusters.Create(DatabaseV1Client, clust).Extract()
 	if err != nil {
-		return diag.Errorf("error creating vkcs_db_cluster: %s", err)
+		return databaseClusterCreateProcessError(err, "vkcs_db_cluster")
 	}
 
 	// Store the ID now
@@ -658,7 +666,11 @@ func resourceDatabaseClusterCreate(ctx context.Context, d *schema.ResourceData,
 
 func resourceDatabaseClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
@@ -725,7 +737,11 @@ func resourceDatabaseClusterRead(ctx context.Context, d *schema.ResourceData, me
 
 func resourceDatabaseClusterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	dbClient, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	dbClient, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
@@ -858,7 +874,11 @@ func resourceDatabaseClusterUpdate(ctx context.Context, d *schema.ResourceData,
 
 func resourceDatabaseClusterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
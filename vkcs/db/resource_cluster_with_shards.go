@@ -8,15 +8,23 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	flavorsutils "github.com/gophercloud/utils/openstack/compute/v2/flavors"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/clients"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/backups"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/clusters"
+	configgroups "github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/config_groups"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/instances"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/networking"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util/errutil"
 )
 
 func ResourceDatabaseClusterWithShards() *schema.Resource {
@@ -25,11 +33,23 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 		ReadContext:   resourceDatabaseClusterWithShardsRead,
 		DeleteContext: resourceDatabaseClusterWithShardsDelete,
 		UpdateContext: resourceDatabaseClusterWithShardsUpdate,
-		CustomizeDiff: resourceDatabaseCustomizeDiff,
+		CustomizeDiff: customdiff.Sequence(
+			resourceDatabaseCustomizeDiff,
+			resourceDatabaseClusterWithShardsCustomizeDiff,
+		),
 		Importer: &schema.ResourceImporter{
 			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+				if strings.Contains(d.Id(), "/") {
+					return nil, fmt.Errorf("invalid import ID %q: shards of vkcs_db_cluster_with_shards can't be "+
+						"managed independently, import the whole cluster by its ID", d.Id())
+				}
+
 				config := meta.(clients.Config)
-				DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+				region, err := util.RequireRegion(d, config)
+				if err != nil {
+					return nil, err
+				}
+				DatabaseV1Client, err := config.DatabaseV1Client(region)
 				if err != nil {
 					return nil, fmt.Errorf("error creating VKCS database client: %s", err)
 				}
@@ -46,6 +66,10 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 				shardIDs := make(map[string]int)
 				shards := make([]map[string]interface{}, 0)
 				for _, inst := range cluster.Instances {
+					// Instances without shard are reported by read
+					if inst.ShardID == "" {
+						continue
+					}
 					if _, ok := shardIDs[inst.ShardID]; ok {
 						shardIDs[inst.ShardID]++
 						continue
@@ -62,10 +86,29 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 					shard["size"] = shardIDs[shard["shard_id"].(string)]
 				}
 				d.Set("shard", shards)
+				d.Set("shard_ids", flattenDatabaseClusterShardIDs(shards))
+				d.Set("backup_before_delete", false)
+				d.Set("continue_on_shard_error", false)
+				d.Set("wait_for_deletion", true)
+				d.Set("allow_version_replacement", false)
+				d.Set("store_root_password", true)
+
+				rootEnabled, err := instances.RootUserGet(DatabaseV1Client, d.Id()).Extract()
+				if err != nil {
+					return nil, fmt.Errorf("error getting root user status of vkcs_db_cluster_with_shards %s: %w", d.Id(), err)
+				}
+				// Root password can't be read back, so it is left empty
+				if rootEnabled {
+					d.Set("root_enabled", true)
+				}
 
 				capabilities, err := clusters.GetCapabilities(DatabaseV1Client, d.Id()).Extract()
 				if err != nil {
-					return nil, fmt.Errorf("error getting cluster capabilities")
+					if !errutil.IsNotFound(err) {
+						return nil, fmt.Errorf("error getting cluster capabilities: %s", err)
+					}
+					log.Printf("[DEBUG] No capabilities found for vkcs_db_cluster_with_shards %s", d.Id())
+					capabilities = nil
 				}
 				d.Set("capabilities", flattenDatabaseInstanceCapabilities(capabilities))
 				return []*schema.ResourceData{d}, nil
@@ -103,14 +146,17 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 							Type:        schema.TypeString,
 							Required:    true,
 							ForceNew:    true,
-							Description: "Version of the datastore. Changing this creates a new cluster.",
+							Description: "Version of the datastore. Changing this creates a new cluster and destroys all of its data, make a backup and restore it to keep the data. Changing it fails to plan unless `allow_version_replacement` is true.",
 						},
 						"type": {
 							Type:         schema.TypeString,
 							Required:     true,
 							ForceNew:     true,
-							ValidateFunc: validation.StringInSlice(getClusterWithShardsDatastores(), true),
-							Description:  fmt.Sprintf("Type of the datastore. Changing this creates a new cluster. Must be one of: %s", strings.Join(datastoresWithQuotes(getClusterWithShardsDatastores()), ", ")),
+							ValidateFunc: validateClusterWithShardsDatastoreType,
+							StateFunc: func(v interface{}) string {
+								return strings.ToLower(v.(string))
+							},
+							Description: fmt.Sprintf("Type of the datastore. Changing this creates a new cluster. Must be one of: %s. The value is case-insensitive.", strings.Join(datastoresWithQuotes(getClusterWithShardsDatastores()), ", ")),
 						},
 					},
 				},
@@ -118,11 +164,20 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 			},
 
 			"configuration_id": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Computed:    false,
-				ForceNew:    false,
-				Description: "The id of the configuration attached to cluster.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      false,
+				ForceNew:      false,
+				ConflictsWith: []string{"configuration_name"},
+				Description:   "The id of the configuration attached to cluster.",
+			},
+
+			"configuration_name": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      false,
+				ConflictsWith: []string{"configuration_id"},
+				Description:   "The name of the configuration attached to cluster. The configuration is looked up among configurations of the cluster datastore. Conflicts with `configuration_id`.",
 			},
 
 			"root_enabled": {
@@ -138,7 +193,14 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 				Sensitive:   true,
 				Computed:    true,
 				ForceNew:    false,
-				Description: "Password for the root user of the cluster. When enabling root, password is autogenerated, use this field to obtain it.",
+				Description: "Password for the root user of the cluster. When enabling root, password is autogenerated, use this field to obtain it. If `store_root_password` is false, the password from this field is used to enable root.",
+			},
+
+			"store_root_password": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether to save autogenerated root password to `root_password` in the state. If false, `root_password` must be set to enable root, so that no password is generated. Note that a password set in `root_password` is always kept in the state. Default is true.",
 			},
 
 			"floating_ip_enabled": {
@@ -149,6 +211,30 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 				Description: "Boolean field that indicates whether floating ip is created for cluster. Changing this creates a new cluster.",
 			},
 
+			"loadbalancer_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The id of the loadbalancer attached to the cluster.",
+			},
+
+			"write_endpoint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The address of the loadbalancer attached to the cluster, which distributes requests among shards. Empty if the cluster has no loadbalancer.",
+			},
+
+			"floating_ip_active": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether any instance of the cluster actually has a floating ip. Unlike `floating_ip_enabled`, it reflects floating ips assigned or removed outside of terraform.",
+			},
+
+			"flavor_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The ID of flavor for shards that specify neither `flavor_id` nor `flavor_name`. Changing this resizes such shards one by one, `continue_on_shard_error` is respected.",
+			},
+
 			"keypair": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -157,6 +243,12 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 				Description: "Name of the keypair to be attached to cluster. Changing this creates a new cluster.",
 			},
 
+			"effective_keypair": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the keypair actually attached to instances of the cluster. It differs from `keypair` if the keypair was replaced outside of terraform.",
+			},
+
 			"disk_autoexpand": {
 				Type:     schema.TypeList,
 				Optional: true,
@@ -171,14 +263,15 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 							Description: "Indicates whether autoresize is enabled.",
 						},
 						"max_disk_size": {
-							Type:        schema.TypeInt,
-							Optional:    true,
-							ForceNew:    false,
-							Description: "Maximum disk size for autoresize.",
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ForceNew:     false,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "Maximum disk size for autoresize.",
 						},
 					},
 				},
-				Description: "Object that represents autoresize properties of the cluster.",
+				Description: "Object that represents autoresize properties of the cluster. The properties are applied to volumes of all instances of all shards.",
 			},
 
 			"wal_disk_autoexpand": {
@@ -195,10 +288,11 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 							Description: "Indicates whether wal volume autoresize is enabled.",
 						},
 						"max_disk_size": {
-							Type:        schema.TypeInt,
-							Optional:    true,
-							ForceNew:    false,
-							Description: "Maximum disk size for wal volume autoresize.",
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ForceNew:     false,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "Maximum disk size for wal volume autoresize.",
 						},
 					},
 				},
@@ -228,6 +322,32 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 				Description: "Object that represents capability applied to cluster. There can be several instances of this object.",
 			},
 
+			"capabilities_effective": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the capability.",
+						},
+						"settings": {
+							Type:        schema.TypeMap,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Map of key-value settings of the capability.",
+						},
+						"status": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The status of the capability.",
+						},
+					},
+				},
+				Description: "Capabilities that are actually applied to the cluster as reported by the server, including settings set by default. Unlike `capabilities`, it is not managed and may contain capabilities and settings that are not declared in the configuration.",
+			},
+
 			"restore_point": {
 				Type:     schema.TypeList,
 				Optional: true,
@@ -246,17 +366,122 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 				Description: "Object that represents backup to restore instance from.",
 			},
 
+			"backup_schedule": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: false,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Name of the schedule.",
+						},
+						"start_hours": {
+							Type:        schema.TypeInt,
+							Required:    true,
+							Description: "Hours part of timestamp of initial backup.",
+						},
+						"start_minutes": {
+							Type:        schema.TypeInt,
+							Required:    true,
+							Description: "Minutes part of timestamp of initial backup.",
+						},
+						"interval_hours": {
+							Type:        schema.TypeInt,
+							Required:    true,
+							Description: "Time interval between backups, specified in hours. Available values: 3, 6, 8, 12, 24.",
+						},
+						"keep_count": {
+							Type:        schema.TypeInt,
+							Required:    true,
+							Description: "Number of backups to be stored.",
+						},
+					},
+				},
+				Description: "Object that represents automatic backup schedule of the cluster. Removing the schedule is not supported.",
+			},
+
 			"cloud_monitoring_enabled": {
 				Type:        schema.TypeBool,
 				Optional:    true,
 				ForceNew:    false,
-				Description: "Enable cloud monitoring for the cluster. Changing this for Redis or MongoDB creates a new instance.",
+				Description: "Enable cloud monitoring for the cluster. Changing this updates the cluster in place.",
+			},
+
+			"allow_version_replacement": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether changing `version` of the datastore may replace the cluster. Replacing destroys the cluster together with its data, so such a change fails to plan unless this is true. Default is false.",
+			},
+
+			"wait_for_deletion": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether to wait for the cluster to be deleted on destroy. If false, destroy returns as soon as deletion is accepted. Default is true.",
+			},
+
+			"continue_on_shard_error": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to keep updating other shards when an action on a shard fails. Errors of all shards are reported at the end of the update. Default is false, the update stops on the first error.",
+			},
+
+			"backup_before_delete": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Create a backup of the cluster and wait for it to complete before deleting the cluster. ID of the backup is written to the provider log. Default is false.",
+			},
+
+			"total_storage_limit_gb": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Limit of total storage of the cluster in gigabytes. Plans where sum of `volume_size` multiplied by `size` over all shards exceeds the limit are rejected.",
+			},
+
+			"shard_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the cluster shards in the order of `shard` blocks.",
+			},
+
+			"backups": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "ID of the backup.",
+						},
+						"created": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Backup creation timestamp.",
+						},
+						"size": {
+							Type:        schema.TypeFloat,
+							Computed:    true,
+							Description: "Backup's volume size.",
+						},
+					},
+				},
+				Description: "Backups of the cluster available to restore from.",
 			},
 
 			"shard": {
 				Type:     schema.TypeList,
 				Required: true,
 				ForceNew: false,
+				MinItems: 1,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"shard_id": {
@@ -267,10 +492,11 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 						},
 
 						"size": {
-							Type:        schema.TypeInt,
-							Required:    true,
-							ForceNew:    false,
-							Description: "The number of instances in the cluster shard.",
+							Type:         schema.TypeInt,
+							Required:     true,
+							ForceNew:     false,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "The number of instances in the cluster shard.",
 						},
 
 						"shrink_options": {
@@ -285,17 +511,23 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 
 						"flavor_id": {
 							Type:        schema.TypeString,
-							Required:    true,
+							Optional:    true,
 							ForceNew:    false,
-							Computed:    false,
-							Description: "The ID of flavor for the cluster shard.",
+							Description: "The ID of flavor for the cluster shard. Either `flavor_id` or `flavor_name` must be specified unless `flavor_id` of the cluster is set. If a shard following `flavor_id` of the cluster actually has another flavor, the flavor is read into this argument, so that the plan resizes the shard back.",
 						},
-						"volume_size": {
-							Type:        schema.TypeInt,
-							Required:    true,
+						"flavor_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
 							ForceNew:    false,
-							Computed:    false,
-							Description: "Size of the cluster shard instance volume.",
+							Description: "The name of flavor for the cluster shard. Either `flavor_id` or `flavor_name` must be specified unless `flavor_id` of the cluster is set.",
+						},
+						"volume_size": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ForceNew:     false,
+							ValidateFunc: validation.IntAtLeast(1),
+							Computed:     false,
+							Description:  "Size of the cluster shard instance volume.",
 						},
 
 						"volume_type": {
@@ -313,22 +545,48 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 							Elem: &schema.Resource{
 								Schema: map[string]*schema.Schema{
 									"size": {
-										Type:        schema.TypeInt,
-										Required:    true,
-										ForceNew:    false,
-										Description: "Size of the instance wal volume.",
+										Type:         schema.TypeInt,
+										Required:     true,
+										ForceNew:     false,
+										ValidateFunc: validation.IntAtLeast(1),
+										Description:  "Size of the instance wal volume.",
 									},
 									"volume_type": {
 										Type:        schema.TypeString,
 										Required:    true,
 										ForceNew:    false,
-										Description: "The type of the cluster wal volume.",
+										Description: "The type of the cluster wal volume. Changing this for an existing shard creates a new cluster.",
 									},
 								},
 							},
 							Description: "Object that represents wal volume of the cluster.",
 						},
 
+						"disk_autoexpand": {
+							Type:     schema.TypeList,
+							Optional: true,
+							ForceNew: false,
+							MaxItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"autoexpand": {
+										Type:        schema.TypeBool,
+										Optional:    true,
+										ForceNew:    false,
+										Description: "Indicates whether autoresize is enabled.",
+									},
+									"max_disk_size": {
+										Type:         schema.TypeInt,
+										Optional:     true,
+										ForceNew:     false,
+										ValidateFunc: validation.IntAtLeast(1),
+										Description:  "Maximum disk size for autoresize.",
+									},
+								},
+							},
+							Description: "Object that represents autoresize properties of the shard instances. Overrides cluster `disk_autoexpand` for the shard.",
+						},
+
 						"network": {
 							Type:     schema.TypeList,
 							Optional: true,
@@ -360,7 +618,7 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 										ForceNew:    true,
 										Elem:        &schema.Schema{Type: schema.TypeString},
 										Set:         schema.HashString,
-										Description: "An array of one or more security group IDs to associate with the shard instances. Changing this creates a new cluster.",
+										Description: "An array of one or more security group IDs to associate with the shard instances. Changing this creates a new cluster, since Databases API does not support updating security groups of existing instances.",
 									},
 								},
 								Description: "Object that represents network of the cluster shard. Changing this creates a new cluster.",
@@ -375,6 +633,13 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 							Description: "The name of the availability zone of the cluster shard. Changing this creates a new cluster.",
 						},
 
+						"operation_timeout": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateDatabaseOperationTimeout,
+							Description:  "Timeout of waiting for update actions of the shard, e.g. \"90m\". Overrides the timeout of the resource for this shard, so that a shard with large volumes does not require increasing it for the whole cluster.",
+						},
+
 						"instances": {
 							Type:     schema.TypeList,
 							Computed: true,
@@ -393,10 +658,44 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 										},
 										Description: "IP address of the instance.",
 									},
+									"port_id": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "ID of the network port of the instance.",
+									},
+									"mac_address": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "MAC address of the network port of the instance.",
+									},
 								},
 							},
 							Description: "Shard instances info.",
 						},
+
+						"instance_count": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The number of shard instances that are active. It differs from `size` while the shard is being grown or shrunk.",
+						},
+
+						"leader": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The ID of the leader instance of the shard. It changes after failover.",
+						},
+
+						"updated_at": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The time the shard instances were last modified, e.g. resized, in RFC3339 format.",
+						},
+
+						"version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Datastore version reported by instances of the shard. It differs from the version of the cluster datastore while shards are being upgraded.",
+						},
 					},
 				},
 				Description: "Object that represents cluster shard. There can be several instances of this object.",
@@ -425,7 +724,11 @@ func ResourceDatabaseClusterWithShards() *schema.Resource {
 
 func resourceDatabaseClusterWithShardsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
@@ -451,9 +754,18 @@ func resourceDatabaseClusterWithShardsCreate(ctx context.Context, d *schema.Reso
 		if err != nil {
 			return diag.Errorf("%s datastore", message)
 		}
+		datastore.Type = strings.ToLower(datastore.Type)
 		createOpts.Datastore = &datastore
 	}
 
+	if v, ok := d.GetOk("backup_schedule"); ok {
+		backupSchedule, err := extractDatabaseBackupSchedule(v.([]interface{}))
+		if err != nil {
+			return diag.Errorf("%s backup_schedule", message)
+		}
+		createOpts.BackupSchedule = &backupSchedule
+	}
+
 	if v, ok := d.GetOk("disk_autoexpand"); ok {
 		autoExpandOpts, err := extractDatabaseAutoExpand(v.([]interface{}))
 		if err != nil {
@@ -480,6 +792,11 @@ func resourceDatabaseClusterWithShardsCreate(ctx context.Context, d *schema.Reso
 		createOpts.WalMaxDiskSize = walAutoExpandOpts.MaxDiskSize
 	}
 
+	flavors, err := resolveDatabaseClusterWithShardsFlavors(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	var instanceCount int
 	shardsRaw := d.Get("shard").([]interface{})
 	shardInfo := make([]clusters.InstanceCreateOpts, len(shardsRaw))
@@ -494,7 +811,7 @@ func resourceDatabaseClusterWithShardsCreate(ctx context.Context, d *schema.Reso
 		shardInfo[i].Volume = &instances.Volume{Size: &volumeSize, VolumeType: shardMap["volume_type"].(string)}
 		shardInfo[i].Nics, shardInfo[i].SecurityGroups, _ = extractDatabaseNetworks(shardMap["network"].([]interface{}))
 		shardInfo[i].AvailabilityZone = shardMap["availability_zone"].(string)
-		shardInfo[i].FlavorRef = shardMap["flavor_id"].(string)
+		shardInfo[i].FlavorRef = getDatabaseClusterShardFlavorID(shardMap, flavors, d.Get("flavor_id").(string))
 		shardInfo[i].ShardID = shardMap["shard_id"].(string)
 		walVolumeV := shardMap["wal_volume"].([]interface{})
 		if len(walVolumeV) > 0 {
@@ -535,10 +852,13 @@ func resourceDatabaseClusterWithShardsCreate(ctx context.Context, d *schema.Reso
 	clust := clusters.Cluster{}
 	clust.Cluster = createOpts
 
-	cluster, err := clusters.Create(DatabaseV1Client, clust).Extract()
+	createResult := clusters.Create(DatabaseV1Client, clust)
+	cluster, err := createResult.Extract()
 	if err != nil {
-		return diag.Errorf("error creating vkcs_db_cluster_with_shards: %s", err)
+		logDatabaseClusterRequest(ctx, "", "Called Databases API to create cluster", createResult.Header)
+		return databaseClusterCreateProcessError(err, "vkcs_db_cluster_with_shards")
 	}
+	logDatabaseClusterRequest(ctx, cluster.ID, "Called Databases API to create cluster", createResult.Header)
 
 	// Store the ID now
 	d.SetId(cluster.ID)
@@ -560,19 +880,22 @@ func resourceDatabaseClusterWithShardsCreate(ctx context.Context, d *schema.Reso
 		return diag.Errorf("error waiting for vkcs_db_cluster_with_shards %s to become ready: %s", cluster.ID, err)
 	}
 
-	if configuration, ok := d.GetOk("configuration_id"); ok {
+	if err := checkDatabaseClusterInstanceCount(DatabaseV1Client, cluster.ID, instanceCount); err != nil {
+		return diag.Errorf("error creating vkcs_db_cluster_with_shards %s: %s", cluster.ID, err)
+	}
+
+	configuration, err := getDatabaseClusterWithShardsConfigurationID(DatabaseV1Client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if configuration != "" {
 		log.Printf("[DEBUG] Attaching configuration %s to vkcs_db_cluster_with_shards %s", configuration, cluster.ID)
 		var attachConfigurationOpts clusters.AttachConfigurationGroupOpts
-		vendorOptionsRaw := d.Get("vendor_options").(*schema.Set)
-		if vendorOptionsRaw.Len() > 0 {
-			vendorOptions := util.ExpandVendorOptions(vendorOptionsRaw.List())
-			if v, ok := vendorOptions["restart_confirmed"]; ok && v.(bool) {
-				restartConfirmed := true
-				attachConfigurationOpts.ConfigurationAttach.RestartConfirmed = &restartConfirmed
-			}
-		}
-		attachConfigurationOpts.ConfigurationAttach.ConfigurationID = configuration.(string)
-		err := clusters.ClusterAction(DatabaseV1Client, cluster.ID, &attachConfigurationOpts).ExtractErr()
+		attachConfigurationOpts.ConfigurationAttach.RestartConfirmed = databaseClusterRestartConfirmed(d)
+		attachConfigurationOpts.ConfigurationAttach.ConfigurationID = configuration
+		r := clusters.ClusterAction(DatabaseV1Client, cluster.ID, &attachConfigurationOpts)
+		logDatabaseClusterRequest(ctx, cluster.ID, "Called Databases API to attach configuration", r.Header)
+		err := r.ExtractErr()
 		if err != nil {
 			return diag.Errorf("error attaching configuration group %s to vkcs_db_cluster_with_shards %s: %s",
 				configuration, cluster.ID, err)
@@ -591,6 +914,35 @@ func resourceDatabaseClusterWithShardsCreate(ctx context.Context, d *schema.Reso
 		if err != nil {
 			return diag.Errorf("error waiting for vkcs_db_cluster_with_shards %s to become ready: %s", cluster.ID, err)
 		}
+
+		stateConf.Refresh = databaseClusterConfigurationStateRefreshFunc(DatabaseV1Client, cluster.ID, configuration)
+		_, err = stateConf.WaitForStateContext(ctx)
+		if err != nil {
+			return diag.Errorf("error waiting for configuration group %s to be attached to vkcs_db_cluster_with_shards %s: %s",
+				configuration, cluster.ID, err)
+		}
+	}
+
+	for _, shardRaw := range shardsRaw {
+		if len(shardRaw.(map[string]interface{})["disk_autoexpand"].([]interface{})) == 0 {
+			continue
+		}
+		updateCtx := &dbResourceUpdateContext{
+			Ctx:    ctx,
+			Client: DatabaseV1Client,
+			D:      d,
+			StateConf: &retry.StateChangeConf{
+				Refresh:    databaseClusterStateRefreshFunc(DatabaseV1Client, cluster.ID, checkCapabilities),
+				Timeout:    d.Timeout(schema.TimeoutCreate),
+				Delay:      dbInstanceDelay,
+				MinTimeout: dbInstanceMinTimeout,
+			},
+		}
+		err = databaseClusterSyncInstancesDiskAutoexpand(updateCtx)
+		if err != nil {
+			return diag.Errorf("error updating disk_autoexpand of vkcs_db_cluster_with_shards %s instances: %s", cluster.ID, err)
+		}
+		break
 	}
 
 	diags := make(diag.Diagnostics, 0)
@@ -603,7 +955,7 @@ func resourceDatabaseClusterWithShardsCreate(ctx context.Context, d *schema.Reso
 				D:         d,
 				StateConf: nil,
 			}
-			err := databaseClusterActionEnableRoot(updateCtx)
+			err := databaseClusterWithShardsEnableRoot(updateCtx)
 			if err.HasError() {
 				return err
 			} else {
@@ -617,12 +969,18 @@ func resourceDatabaseClusterWithShardsCreate(ctx context.Context, d *schema.Reso
 
 func resourceDatabaseClusterWithShardsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
 
-	cluster, err := clusters.Get(DatabaseV1Client, d.Id()).Extract()
+	getResult := clusters.Get(DatabaseV1Client, d.Id())
+	logDatabaseClusterRequest(ctx, d.Id(), "Called Databases API to read cluster", getResult.Header)
+	cluster, err := getResult.Extract()
 	if err != nil {
 		return diag.FromErr(util.CheckDeleted(d, err, "error retrieving vkcs_db_cluster_with_shards"))
 	}
@@ -630,9 +988,51 @@ func resourceDatabaseClusterWithShardsRead(ctx context.Context, d *schema.Resour
 	log.Printf("[DEBUG] Retrieved vkcs_db_cluster_with_shards %s: %#v", d.Id(), cluster)
 
 	d.Set("name", cluster.Name)
-	d.Set("datastore", flattenDatabaseInstanceDatastore(*cluster.DataStore))
-
-	d.Set("configuration_id", cluster.ConfigurationID)
+	d.Set("region", region)
+	d.Set("loadbalancer_id", cluster.LoadbalancerID)
+	// Errors of reading details of the cluster are reported as a single
+	// warning.
+	var detailErrs []string
+	var writeEndpoint string
+	if cluster.LoadbalancerID != "" {
+		lbClient, err := config.LoadBalancerV2Client(region)
+		if err == nil {
+			writeEndpoint, err = getDatabaseClusterWriteEndpoint(lbClient, cluster.LoadbalancerID)
+		}
+		if err != nil {
+			detailErrs = append(detailErrs, fmt.Sprintf("loadbalancer %s: %s", cluster.LoadbalancerID, err))
+		}
+	}
+	d.Set("write_endpoint", writeEndpoint)
+
+	computeClient, err := config.ComputeV2Client(region)
+	if err == nil {
+		var keypair string
+		keypair, err = databaseClusterEffectiveKeypair(computeClient, cluster.Instances, d.Get("keypair").(string))
+		if err == nil {
+			d.Set("effective_keypair", keypair)
+		}
+	}
+	if err != nil {
+		detailErrs = append(detailErrs, fmt.Sprintf("keypair of instances: %s", err))
+	}
+	datastore := *cluster.DataStore
+	datastore.Type = strings.ToLower(datastore.Type)
+	d.Set("datastore", flattenDatabaseInstanceDatastore(datastore))
+
+	if _, ok := d.GetOk("configuration_name"); ok {
+		var configurationName string
+		if cluster.ConfigurationID != "" {
+			configGroup, err := configgroups.Get(DatabaseV1Client, cluster.ConfigurationID).Extract()
+			if err != nil {
+				return diag.Errorf("error retrieving configuration %s of vkcs_db_cluster_with_shards %s: %s", cluster.ConfigurationID, d.Id(), err)
+			}
+			configurationName = configGroup.Name
+		}
+		d.Set("configuration_name", configurationName)
+	} else {
+		d.Set("configuration_id", cluster.ConfigurationID)
+	}
 	if _, ok := d.GetOk("disk_autoexpand"); ok {
 		d.Set("disk_autoexpand", flattenDatabaseInstanceAutoExpand(cluster.AutoExpand, cluster.MaxDiskSize))
 	}
@@ -640,11 +1040,52 @@ func resourceDatabaseClusterWithShardsRead(ctx context.Context, d *schema.Resour
 		d.Set("wal_disk_autoexpand", flattenDatabaseInstanceAutoExpand(cluster.WalAutoExpand, cluster.WalMaxDiskSize))
 	}
 
+	backupScheduleResult := clusters.GetBackupSchedule(DatabaseV1Client, d.Id())
+	logDatabaseClusterRequest(ctx, d.Id(), "Called Databases API to read backup schedule", backupScheduleResult.Header)
+	backupSchedule, err := backupScheduleResult.Extract()
+	switch {
+	case err == nil && backupSchedule != nil:
+		d.Set("backup_schedule", flattenDatabaseBackupSchedule(*backupSchedule))
+	case err == nil, databaseClusterBackupScheduleUnsupported(err):
+		d.Set("backup_schedule", nil)
+	default:
+		log.Printf("[WARN] Unable to get backup schedule of vkcs_db_cluster_with_shards %s: %s", d.Id(), err)
+	}
+
+	capabilitiesResult := clusters.GetCapabilities(DatabaseV1Client, d.Id())
+	logDatabaseClusterRequest(ctx, d.Id(), "Called Databases API to read capabilities", capabilitiesResult.Header)
+	capabilities, err := capabilitiesResult.Extract()
+	switch {
+	case err == nil:
+		d.Set("capabilities_effective", flattenDatabaseClusterEffectiveCapabilities(capabilities))
+	case errutil.IsNotFound(err):
+		d.Set("capabilities_effective", nil)
+	default:
+		log.Printf("[WARN] Unable to get capabilities of vkcs_db_cluster_with_shards %s: %s", d.Id(), err)
+	}
+
+	clusterBackups, err := getDatabaseClusterBackups(DatabaseV1Client, d.Id())
+	if err != nil {
+		log.Printf("[WARN] Unable to list backups of vkcs_db_cluster_with_shards %s: %s", d.Id(), err)
+	} else {
+		d.Set("backups", clusterBackups)
+	}
+
 	hasChanges := d.HasChangesExcept()
 
 	var diags diag.Diagnostics
 
 	shardsInstances := getDatabaseClusterShardInstances(cluster.Instances)
+	if orphanIDs := databaseClusterOrphanInstanceIDs(shardsInstances); len(orphanIDs) > 0 {
+		delete(shardsInstances, "")
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Instances without shard found",
+			Detail: fmt.Sprintf("Instances %s of vkcs_db_cluster_with_shards %s are not assigned to any shard, "+
+				"so they are not counted in shards. They may be left after a failed grow, please check and delete them.",
+				strings.Join(orphanIDs, ", "), d.Id()),
+		})
+	}
 	flattenedShards := flattenDatabaseClusterShards(shardsInstances)
 	// Workaround to persist user order of shards
 	sort.Slice(flattenedShards, func(i, j int) bool {
@@ -652,6 +1093,7 @@ func resourceDatabaseClusterWithShardsRead(ctx context.Context, d *schema.Resour
 	})
 
 	rawShards := d.Get("shard").([]interface{})
+	flavorNames := make(map[string]string)
 	shards := make([]map[string]interface{}, 0, len(flattenedShards))
 	newShards := make([]map[string]interface{}, 0, len(flattenedShards))
 
@@ -659,7 +1101,19 @@ OuterLoop:
 	for _, fSh := range flattenedShards {
 		for _, rawSh := range rawShards {
 			rawShMap := rawSh.(map[string]interface{})
-			if fSh["shard_id"].(string) == rawShMap["shard_id"].(string) {
+			if shardID := fSh["shard_id"].(string); shardID == rawShMap["shard_id"].(string) {
+				flavorID := databaseClusterShardFlavorID(shardsInstances[shardID], "")
+				fSh["flavor_id"] = databaseClusterShardStateFlavorID(rawShMap, flavorID, d.Get("flavor_id").(string))
+				if rawShMap["flavor_name"].(string) != "" && flavorID != "" {
+					if _, ok := flavorNames[flavorID]; !ok {
+						flavorNames[flavorID], err = getDatabaseClusterFlavorName(config, d, flavorID)
+						if err != nil {
+							return diag.FromErr(err)
+						}
+					}
+					fSh["flavor_name"] = flavorNames[flavorID]
+				}
+				fSh["operation_timeout"] = rawShMap["operation_timeout"]
 				shards = append(shards, fSh)
 				continue OuterLoop
 			}
@@ -668,8 +1122,55 @@ OuterLoop:
 	}
 
 	shards = append(shards, newShards...)
+
+	networkingClient, err := config.NetworkingV2Client(region, networking.SearchInAllSDNs)
+	if err != nil {
+		detailErrs = append(detailErrs, fmt.Sprintf("networking client: %s", err))
+	}
+	floatingIPKnown := networkingClient != nil
+	var floatingIPActive bool
 	for i := range shards {
+		shardID := shards[i]["shard_id"].(string)
+		shardInstDetails, err := getDatabaseClusterShardInstanceDetails(DatabaseV1Client, shardsInstances[shardID])
+		if err != nil {
+			detailErrs = append(detailErrs, fmt.Sprintf("instances of shard %s: %s", shardID, err))
+		}
+		shards[i]["version"] = databaseClusterShardDatastoreVersion(shardInstDetails, cluster.DataStore.Version)
+		shards[i]["updated_at"] = databaseClusterShardUpdatedAt(shardInstDetails)
+
+		if networkingClient != nil {
+			insts, _ := shards[i]["instances"].([]map[string]interface{})
+			for j, inst := range shardsInstances[shardID] {
+				var ips []string
+				if inst.IP != nil {
+					ips = *inst.IP
+				}
+				port, err := getDatabaseClusterInstancePort(networkingClient, inst.СomputeInstanceID, ips)
+				if err != nil {
+					detailErrs = append(detailErrs, fmt.Sprintf("port of instance %s: %s", inst.ID, err))
+					floatingIPKnown = false
+				}
+				if port == nil {
+					continue
+				}
+				if j < len(insts) {
+					insts[j]["port_id"] = port.ID
+					insts[j]["mac_address"] = port.MACAddress
+				}
+				if !floatingIPKnown || floatingIPActive {
+					continue
+				}
+				floatingIP, err := getDatabaseClusterPortFloatingIP(networkingClient, port.ID)
+				floatingIPActive = floatingIP != ""
+				if err != nil {
+					detailErrs = append(detailErrs, fmt.Sprintf("floating ips of port %s: %s", port.ID, err))
+					floatingIPKnown = false
+				}
+			}
+		}
+
 		shards[i]["availability_zone"] = d.Get(fmt.Sprintf("shard.%d.availability_zone", i))
+		shards[i]["disk_autoexpand"] = d.Get(fmt.Sprintf("shard.%d.disk_autoexpand", i))
 		shards[i]["network"] = d.Get(fmt.Sprintf("shard.%d.network", i))
 
 		// Workaround since we don't retrieve info about volume_type
@@ -678,11 +1179,9 @@ OuterLoop:
 		if v, ok := d.GetOk(fmt.Sprintf("shard.%d.volume_type", i)); ok {
 			shards[i]["volume_type"] = v
 		}
-		if v, ok := d.GetOk(fmt.Sprintf("shard.%d.wal_volume.volume_type", i)); ok {
-			if wV, ok := shards[i]["wal_volume"]; ok {
-				m := wV.(map[string]interface{})
-				m["volume_type"] = v
-				shards[i]["wal_volume"] = m
+		if v, ok := d.GetOk(fmt.Sprintf("shard.%d.wal_volume.0.volume_type", i)); ok {
+			if wV, ok := shards[i]["wal_volume"].([]map[string]interface{}); ok && len(wV) > 0 {
+				wV[0]["volume_type"] = v
 			}
 		}
 
@@ -700,12 +1199,29 @@ OuterLoop:
 	log.Printf("[DEBUG] Retrieved shards for vkcs_db_cluster_with_shards %s: %#v", d.Id(), flattenedShards)
 
 	d.Set("shard", shards)
+	d.Set("shard_ids", flattenDatabaseClusterShardIDs(shards))
+	if floatingIPKnown {
+		d.Set("floating_ip_active", floatingIPActive)
+	}
+	if len(detailErrs) > 0 {
+		log.Printf("[WARN] Unable to read details of vkcs_db_cluster_with_shards %s: %s", d.Id(), strings.Join(detailErrs, "; "))
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Unable to read some details of the cluster",
+			Detail: fmt.Sprintf("Some computed attributes of vkcs_db_cluster_with_shards %s are not refreshed: %s",
+				d.Id(), strings.Join(detailErrs, "; ")),
+		})
+	}
 	return diags
 }
 
 func resourceDatabaseClusterWithShardsUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	dbClient, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	dbClient, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
@@ -726,10 +1242,25 @@ func resourceDatabaseClusterWithShardsUpdate(ctx context.Context, d *schema.Reso
 		StateConf: stateConf,
 	}
 
-	if d.HasChange("configuration_id") {
-		err = databaseClusterActionUpdateConfiguration(updateCtx)
+	err = databaseClusterWaitForPendingOperation(updateCtx)
+	if err != nil {
+		return databaseClusterWithShardsUpdateProcessError(err, clusterID, "")
+	}
+
+	if d.HasChanges("configuration_id", "configuration_name") {
+		cluster, err := clusters.Get(dbClient, clusterID).Extract()
 		if err != nil {
-			return databaseClusterWithShardsUpdateProcessError(err, clusterID, "")
+			return diag.FromErr(util.CheckDeleted(d, err, "error retrieving vkcs_db_cluster_with_shards"))
+		}
+		configuration, err := getDatabaseClusterWithShardsConfigurationID(dbClient, d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if configuration != cluster.ConfigurationID {
+			err = databaseClusterActionUpdateConfigurationByID(updateCtx, cluster.ConfigurationID, configuration)
+			if err != nil {
+				return databaseClusterWithShardsUpdateProcessError(err, clusterID, "")
+			}
 		}
 	}
 
@@ -761,54 +1292,156 @@ func resourceDatabaseClusterWithShardsUpdate(ctx context.Context, d *schema.Reso
 		}
 	}
 
+	if d.HasChange("backup_schedule") {
+		err = databaseClusterUpdateBackupSchedule(updateCtx)
+		if err != nil {
+			return databaseClusterWithShardsUpdateProcessError(err, clusterID, "")
+		}
+	}
+
+	flavors, err := resolveDatabaseClusterWithShardsFlavors(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var growShardIDs, shrinkShardIDs []string
+	continueOnShardError := d.Get("continue_on_shard_error").(bool)
+	var shardDiags diag.Diagnostics
+	failedShardIDs := make(map[string]bool)
+	// processShardError returns true if the update must stop because of the
+	// error, otherwise the error is collected and the shard is skipped.
+	processShardError := func(err error, shardID string) bool {
+		shardDiags = append(shardDiags, databaseClusterWithShardsUpdateProcessError(err, clusterID, shardID)...)
+		failedShardIDs[shardID] = true
+		return !continueOnShardError
+	}
+
+	syncAutoexpand := d.HasChange("disk_autoexpand")
 	shardsRaw := d.Get("shard").([]interface{})
+	shardUpdateCtxs := make(map[string]*dbResourceUpdateContext, len(shardsRaw))
+	shardFlavorIDs := make(map[string]string, len(shardsRaw))
 	for i, shardRaw := range shardsRaw {
 		shard := shardRaw.(map[string]interface{})
 		shardID := shard["shard_id"].(string)
 		pathPrefix := fmt.Sprintf("shard.%d.", i)
+		shardUpdateCtxs[shardID] = databaseClusterShardUpdateContext(updateCtx, shard)
+		shardFlavorIDs[shardID] = getDatabaseClusterShardFlavorID(shard, flavors, d.Get("flavor_id").(string))
+
+		if d.HasChanges(pathPrefix+"disk_autoexpand", pathPrefix+"size") {
+			syncAutoexpand = true
+		}
 
 		if p := pathPrefix + "volume_size"; d.HasChange(p) {
-			err = databaseClusterActionResizeVolume(updateCtx, shardID)
+			err = databaseClusterActionResizeVolume(shardUpdateCtxs[shardID], shardID)
 			if err != nil {
-				return databaseClusterWithShardsUpdateProcessError(err, clusterID, shardID)
+				if processShardError(err, shardID) {
+					return shardDiags
+				}
+				continue
 			}
 		}
 
 		if p := pathPrefix + "wal_volume"; d.HasChange(p) {
-			err = databaseClusterActionResizeWalVolume(updateCtx, shardID)
+			err = databaseClusterActionResizeWalVolume(shardUpdateCtxs[shardID], shardID)
 			if err != nil {
-				return databaseClusterWithShardsUpdateProcessError(err, clusterID, shardID)
+				if processShardError(err, shardID) {
+					return shardDiags
+				}
+				continue
 			}
 		}
 
-		if p := pathPrefix + "flavor_id"; d.HasChange(p) {
-			err = databaseClusterActionResizeFlavor(updateCtx, shardID)
+		// flavor_id of a shard following flavor_id of the cluster is set in
+		// the state only if the shard actually has another flavor.
+		oldFlavorID, _ := d.GetChange(pathPrefix + "flavor_id")
+		flavorChanged := d.HasChanges(pathPrefix+"flavor_id", pathPrefix+"flavor_name")
+		if databaseClusterShardUsesDefaultFlavor(shard) {
+			flavorChanged = d.HasChanges("flavor_id", pathPrefix+"flavor_id")
+		}
+		if flavorID := shardFlavorIDs[shardID]; flavorChanged && flavorID != oldFlavorID.(string) {
+			log.Printf("[DEBUG] Resizing shard %s (%d of %d) of vkcs_db_cluster_with_shards %s to flavor %s",
+				shardID, i+1, len(shardsRaw), clusterID, flavorID)
+			err = databaseClusterActionResizeFlavorByID(shardUpdateCtxs[shardID], shardID, flavorID)
 			if err != nil {
-				return databaseClusterWithShardsUpdateProcessError(err, clusterID, shardID)
+				log.Printf("[DEBUG] Failed to resize shard %s (%d of %d) of vkcs_db_cluster_with_shards %s: %s",
+					shardID, i+1, len(shardsRaw), clusterID, err)
+				if processShardError(err, shardID) {
+					return shardDiags
+				}
+				continue
 			}
+			log.Printf("[DEBUG] Resized shard %s (%d of %d) of vkcs_db_cluster_with_shards %s to flavor %s",
+				shardID, i+1, len(shardsRaw), clusterID, flavorID)
 		}
 
 		if p := pathPrefix + "size"; d.HasChange(p) {
 			old, new := d.GetChange(p)
 			if sizeChange := new.(int) - old.(int); sizeChange > 0 {
-				err = databaseClusterActionGrow(updateCtx, shardID)
+				growShardIDs = append(growShardIDs, shardID)
 			} else if sizeChange < 0 {
-				err = databaseClusterActionShrink(updateCtx, shardID)
-			}
-			if err != nil {
-				return databaseClusterWithShardsUpdateProcessError(err, clusterID, shardID)
+				shrinkShardIDs = append(shrinkShardIDs, shardID)
 			}
 		}
 	}
 
+	// Shards are shrunk before others are grown, so that grow and shrink are
+	// never run at the same time and freed resources are available for grow.
+	for _, shardID := range shrinkShardIDs {
+		if failedShardIDs[shardID] {
+			continue
+		}
+		err = databaseClusterActionShrink(shardUpdateCtxs[shardID], shardID)
+		if err != nil && processShardError(err, shardID) {
+			return shardDiags
+		}
+	}
+	for _, shardID := range growShardIDs {
+		if failedShardIDs[shardID] {
+			continue
+		}
+		err = databaseClusterActionGrowWithFlavorID(shardUpdateCtxs[shardID], shardID, shardFlavorIDs[shardID])
+		if err != nil && processShardError(err, shardID) {
+			return shardDiags
+		}
+	}
+
 	diags := make(diag.Diagnostics, 0)
 
+	// Failed shards keep their previous attributes in state, so that the
+	// next plan retries them, and the rest of the update still runs.
+	if len(shardDiags) > 0 {
+		var updatedShardIDs, failedIDs []string
+		for _, shardRaw := range shardsRaw {
+			shardID := shardRaw.(map[string]interface{})["shard_id"].(string)
+			if failedShardIDs[shardID] {
+				failedIDs = append(failedIDs, shardID)
+			} else {
+				updatedShardIDs = append(updatedShardIDs, shardID)
+			}
+		}
+		oldShards, _ := d.GetChange("shard")
+		d.Set("shard", databaseClusterRevertFailedShards(oldShards.([]interface{}), shardsRaw, failedShardIDs))
+		diags = append(shardDiags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Shards of vkcs_db_cluster_with_shards are partially updated",
+			Detail: fmt.Sprintf("Failed shards: %s. Other shards: %s. Failed shards are retried on the next apply.",
+				strings.Join(failedIDs, ", "), strings.Join(updatedShardIDs, ", ")),
+		})
+	}
+
+	if syncAutoexpand {
+		err = databaseClusterSyncInstancesDiskAutoexpand(updateCtx)
+		if err != nil {
+			return append(diags, databaseClusterWithShardsUpdateProcessError(err, clusterID, "")...)
+		}
+	}
+
 	if d.HasChange("root_enabled") {
 		_, new := d.GetChange("root_enabled")
 		if new == true {
-			err := databaseClusterActionEnableRoot(updateCtx)
+			err := databaseClusterWithShardsEnableRoot(updateCtx)
 			if err.HasError() {
-				return err
+				return append(diags, err...)
 			} else {
 				diags = append(diags, err...)
 			}
@@ -823,16 +1456,61 @@ func resourceDatabaseClusterWithShardsUpdate(ctx context.Context, d *schema.Reso
 
 func resourceDatabaseClusterWithShardsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
 
-	err = clusters.Delete(DatabaseV1Client, d.Id()).ExtractErr()
+	// Detach configuration first so that it could be deleted right after the cluster
+	getResult := clusters.Get(DatabaseV1Client, d.Id())
+	logDatabaseClusterRequest(ctx, d.Id(), "Called Databases API to read cluster", getResult.Header)
+	cluster, err := getResult.Extract()
+	if err != nil {
+		return diag.FromErr(util.CheckDeleted(d, err, "Error retrieving vkcs_db_cluster_with_shards"))
+	}
+
+	if d.Get("backup_before_delete").(bool) {
+		backupID, err := databaseClusterBackupBeforeDelete(ctx, DatabaseV1Client, cluster, d.Timeout(schema.TimeoutDelete))
+		if err != nil {
+			return diag.Errorf("error backing up vkcs_db_cluster_with_shards %s before deletion: %s", d.Id(), err)
+		}
+		log.Printf("[INFO] Created backup %s of vkcs_db_cluster_with_shards %s before deletion", backupID, d.Id())
+	}
+
+	if cluster.ConfigurationID != "" {
+		updateCtx := &dbResourceUpdateContext{
+			Ctx:    ctx,
+			Client: DatabaseV1Client,
+			D:      d,
+			StateConf: &retry.StateChangeConf{
+				Refresh:    databaseClusterStateRefreshFunc(DatabaseV1Client, d.Id(), nil),
+				Timeout:    d.Timeout(schema.TimeoutDelete),
+				Delay:      dbInstanceDelay,
+				MinTimeout: dbInstanceMinTimeout,
+			},
+		}
+		err = databaseClusterActionUpdateConfigurationByID(updateCtx, cluster.ConfigurationID, "")
+		if err != nil {
+			return databaseClusterWithShardsUpdateProcessError(err, d.Id(), "")
+		}
+	}
+
+	deleteResult := clusters.Delete(DatabaseV1Client, d.Id())
+	logDatabaseClusterRequest(ctx, d.Id(), "Called Databases API to delete cluster", deleteResult.Header)
+	err = deleteResult.ExtractErr()
 	if err != nil {
 		return diag.FromErr(util.CheckDeleted(d, err, "Error deleting vkcs_db_cluster_with_shards"))
 	}
 
+	if !d.Get("wait_for_deletion").(bool) {
+		log.Printf("[DEBUG] Not waiting for vkcs_db_cluster_with_shards %s to delete", d.Id())
+		return nil
+	}
+
 	stateConf := &retry.StateChangeConf{
 		Pending:    []string{string(dbClusterStatusActive), string(dbClusterStatusDeleting)},
 		Target:     []string{string(dbClusterStatusDeleted)},
@@ -850,6 +1528,297 @@ func resourceDatabaseClusterWithShardsDelete(ctx context.Context, d *schema.Reso
 	return nil
 }
 
+func resourceDatabaseClusterWithShardsCustomizeDiff(_ context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if err := resourceDatabaseClusterWithShardsValidateCapabilities(diff, meta); err != nil {
+		return err
+	}
+	if err := resourceDatabaseClusterWithShardsValidateRestorePoint(diff, meta); err != nil {
+		return err
+	}
+
+	rawShards := diff.GetRawConfig().GetAttr("shard")
+	if !rawShards.IsKnown() || rawShards.IsNull() {
+		return nil
+	}
+
+	hasDefaultFlavor := !diff.GetRawConfig().GetAttr("flavor_id").IsNull()
+	for i, rawShard := range rawShards.AsValueSlice() {
+		if !rawShard.IsKnown() || rawShard.IsNull() {
+			continue
+		}
+		hasFlavorID := !rawShard.GetAttr("flavor_id").IsNull()
+		hasFlavorName := !rawShard.GetAttr("flavor_name").IsNull()
+		if hasFlavorID && hasFlavorName {
+			return fmt.Errorf("exactly one of flavor_id or flavor_name must be specified for shard.%d", i)
+		}
+		if !hasFlavorID && !hasFlavorName && !hasDefaultFlavor {
+			return fmt.Errorf("exactly one of flavor_id or flavor_name must be specified for shard.%d "+
+				"unless flavor_id of the cluster is set", i)
+		}
+	}
+
+	if err := resourceDatabaseClusterWithShardsValidateRootPassword(diff); err != nil {
+		return err
+	}
+
+	if err := resourceDatabaseClusterWithShardsValidateAutoexpand(diff); err != nil {
+		return err
+	}
+
+	if limit, ok := diff.GetOk("total_storage_limit_gb"); ok {
+		var totalStorage int
+		for _, shardRaw := range diff.Get("shard").([]interface{}) {
+			shard := shardRaw.(map[string]interface{})
+			totalStorage += shard["volume_size"].(int) * shard["size"].(int)
+		}
+		if totalStorage > limit.(int) {
+			return fmt.Errorf("total storage of shards %d GB exceeds total_storage_limit_gb %d GB", totalStorage, limit.(int))
+		}
+	}
+
+	if diff.Id() == "" {
+		return nil
+	}
+
+	if diff.HasChange("shard") {
+		var shardIDs []string
+		for _, shardRaw := range diff.Get("shard").([]interface{}) {
+			shardIDs = append(shardIDs, shardRaw.(map[string]interface{})["shard_id"].(string))
+		}
+		if err := diff.SetNew("shard_ids", shardIDs); err != nil {
+			return err
+		}
+	}
+
+	if diff.HasChange("backup_schedule") && len(diff.Get("backup_schedule").([]interface{})) == 0 {
+		return fmt.Errorf("backup_schedule of vkcs_db_cluster_with_shards %s can't be removed, "+
+			"since Databases API does not support removing the schedule", diff.Id())
+	}
+
+	if diff.HasChange("datastore.0.version") && !diff.Get("allow_version_replacement").(bool) {
+		old, new := diff.GetChange("datastore.0.version")
+		return fmt.Errorf("changing datastore version of vkcs_db_cluster_with_shards %s from %s to %s destroys "+
+			"the cluster together with its data: create a backup and restore it to a new cluster to keep the data, "+
+			"or set allow_version_replacement to true", diff.Id(), old, new)
+	}
+
+	// Volume type of wal volume can't be changed in place
+	oldShards, _ := diff.GetChange("shard")
+	for i, oldShardRaw := range oldShards.([]interface{}) {
+		oldShard := oldShardRaw.(map[string]interface{})
+		if len(oldShard["wal_volume"].([]interface{})) == 0 {
+			continue
+		}
+		if diff.Get(fmt.Sprintf("shard.%d.shard_id", i)) != oldShard["shard_id"] {
+			continue
+		}
+		if p := fmt.Sprintf("shard.%d.wal_volume.0.volume_type", i); diff.HasChange(p) {
+			if err := diff.ForceNew(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourceDatabaseClusterWithShardsValidateRootPassword requires root_password
+// to enable root when the generated password is not stored, since there is no
+// safe way to hand a generated password over to the user.
+func resourceDatabaseClusterWithShardsValidateRootPassword(diff *schema.ResourceDiff) error {
+	if diff.Get("store_root_password").(bool) || !diff.Get("root_enabled").(bool) {
+		return nil
+	}
+	rawConfig := diff.GetRawConfig()
+	if rawConfig.IsNull() || !rawConfig.GetAttr("root_password").IsNull() {
+		return nil
+	}
+	return fmt.Errorf("root_password must be set to enable root when store_root_password is false")
+}
+
+// resourceDatabaseClusterWithShardsValidateAutoexpand requires max_disk_size to
+// be set wherever autoexpand is enabled, since it has no effect without it.
+func resourceDatabaseClusterWithShardsValidateAutoexpand(diff *schema.ResourceDiff) error {
+	paths := []string{"disk_autoexpand", "wal_disk_autoexpand"}
+	for i := range diff.Get("shard").([]interface{}) {
+		paths = append(paths, fmt.Sprintf("shard.%d.disk_autoexpand", i))
+	}
+
+	for _, p := range paths {
+		if !diff.Get(p + ".0.autoexpand").(bool) {
+			continue
+		}
+		if diff.Get(p+".0.max_disk_size").(int) <= 0 {
+			return fmt.Errorf("%s.0.max_disk_size must be set to a positive value when %s.0.autoexpand is true", p, p)
+		}
+	}
+	return nil
+}
+
+// resourceDatabaseClusterWithShardsValidateCapabilities checks settings of
+// changed capabilities against the datastore, so that a wrong setting is
+// reported at plan time instead of being applied to the cluster.
+func resourceDatabaseClusterWithShardsValidateCapabilities(diff *schema.ResourceDiff, meta interface{}) error {
+	if !diff.HasChange("capabilities") || !diff.NewValueKnown("capabilities") {
+		return nil
+	}
+	capabilities, err := extractDatabaseCapabilities(diff.Get("capabilities").([]interface{}))
+	if err != nil || len(capabilities) == 0 {
+		return nil
+	}
+
+	config := meta.(clients.Config)
+	region, err := util.RequireRegion(diff, config)
+	if err != nil {
+		return err
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
+	if err != nil {
+		return fmt.Errorf("error creating VKCS database client: %s", err)
+	}
+
+	dsType, dsVersion := strings.ToLower(diff.Get("datastore.0.type").(string)), diff.Get("datastore.0.version").(string)
+	available, err := getDatabaseDatastoreCapabilities(DatabaseV1Client, dsType, dsVersion)
+	if err != nil {
+		log.Printf("[WARN] Unable to validate capabilities of vkcs_db_cluster_with_shards: %s", err)
+		return nil
+	}
+
+	return validateDatabaseCapabilities(capabilities, available)
+}
+
+// resourceDatabaseClusterWithShardsValidateRestorePoint checks that the backup
+// to restore from matches the datastore of the new cluster, so that a doomed
+// restore is reported at plan time instead of failing after a long create.
+func resourceDatabaseClusterWithShardsValidateRestorePoint(diff *schema.ResourceDiff, meta interface{}) error {
+	if diff.Id() != "" || !diff.NewValueKnown("restore_point.0.backup_id") {
+		return nil
+	}
+	backupID := diff.Get("restore_point.0.backup_id").(string)
+	if backupID == "" {
+		return nil
+	}
+
+	config := meta.(clients.Config)
+	region, err := util.RequireRegion(diff, config)
+	if err != nil {
+		return err
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
+	if err != nil {
+		return fmt.Errorf("error creating VKCS database client: %s", err)
+	}
+
+	backup, err := backups.Get(DatabaseV1Client, backupID).Extract()
+	if err != nil {
+		log.Printf("[WARN] Unable to validate restore_point of vkcs_db_cluster_with_shards: %s", err)
+		return nil
+	}
+
+	return validateDatabaseBackupDatastore(backup, diff.Get("datastore.0.type").(string), diff.Get("datastore.0.version").(string))
+}
+
+// resolveDatabaseClusterWithShardsFlavors looks up IDs of the flavors that are
+// referenced by name in shards. Every name is looked up only once.
+func resolveDatabaseClusterWithShardsFlavors(d *schema.ResourceData, config clients.Config) (map[string]string, error) {
+	flavors := make(map[string]string)
+	var computeClient *gophercloud.ServiceClient
+	for _, shardRaw := range d.Get("shard").([]interface{}) {
+		flavorName := shardRaw.(map[string]interface{})["flavor_name"].(string)
+		if _, ok := flavors[flavorName]; ok || flavorName == "" {
+			continue
+		}
+
+		if computeClient == nil {
+			region, err := util.RequireRegion(d, config)
+			if err != nil {
+				return nil, err
+			}
+			computeClient, err = config.ComputeV2Client(region)
+			if err != nil {
+				return nil, fmt.Errorf("error creating VKCS compute client: %s", err)
+			}
+		}
+
+		flavorID, err := flavorsutils.IDFromName(computeClient, flavorName)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving flavor %s: %s", flavorName, err)
+		}
+		flavors[flavorName] = flavorID
+	}
+
+	return flavors, nil
+}
+
+func getDatabaseClusterShardFlavorID(shard map[string]interface{}, flavors map[string]string, defaultFlavorID string) string {
+	if flavorName := shard["flavor_name"].(string); flavorName != "" {
+		return flavors[flavorName]
+	}
+	if flavorID := shard["flavor_id"].(string); flavorID != "" {
+		return flavorID
+	}
+	return defaultFlavorID
+}
+
+// databaseClusterShardUsesDefaultFlavor reports whether the shard specifies
+// no flavor and thus follows flavor_id of the cluster.
+func databaseClusterShardUsesDefaultFlavor(shard map[string]interface{}) bool {
+	return shard["flavor_id"].(string) == "" && shard["flavor_name"].(string) == ""
+}
+
+// databaseClusterShardStateFlavorID returns flavor_id of the shard to be read
+// into the state. flavor_id of a shard that specifies flavor_name is kept
+// empty, since its drift is read into flavor_name. flavor_id of a shard
+// following defaultFlavorID is kept empty unless the shard actually has
+// another flavor.
+func databaseClusterShardStateFlavorID(shard map[string]interface{}, flavorID, defaultFlavorID string) string {
+	if shard["flavor_name"].(string) != "" {
+		return ""
+	}
+	if shard["flavor_id"].(string) == "" && flavorID == defaultFlavorID {
+		return ""
+	}
+	return flavorID
+}
+
+func getDatabaseClusterFlavorName(config clients.Config, d *schema.ResourceData, flavorID string) (string, error) {
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return "", err
+	}
+	computeClient, err := config.ComputeV2Client(region)
+	if err != nil {
+		return "", fmt.Errorf("error creating VKCS compute client: %s", err)
+	}
+
+	flavor, err := flavors.Get(computeClient, flavorID).Extract()
+	if err != nil {
+		return "", fmt.Errorf("error retrieving flavor %s: %s", flavorID, err)
+	}
+
+	return flavor.Name, nil
+}
+
+// getDatabaseClusterWithShardsConfigurationID returns id of the configuration
+// that should be attached to the cluster, resolving configuration_name if needed.
+func getDatabaseClusterWithShardsConfigurationID(client *gophercloud.ServiceClient, d *schema.ResourceData) (string, error) {
+	if v, ok := d.GetOk("configuration_id"); ok {
+		return v.(string), nil
+	}
+
+	name, ok := d.GetOk("configuration_name")
+	if !ok {
+		return "", nil
+	}
+
+	datastore, err := extractDatabaseDatastore(d.Get("datastore").([]interface{}))
+	if err != nil {
+		return "", fmt.Errorf("unable to determine vkcs_db_cluster_with_shards datastore: %s", err)
+	}
+
+	return getDatabaseConfigGroupIDByName(client, name.(string), datastore)
+}
+
 func databaseClusterWithShardsUpdateProcessError(err error, clusterID string, shardID string) diag.Diagnostics {
 	baseErr := err
 	if unwrappedErr := errors.Unwrap(err); unwrappedErr != nil {
@@ -875,6 +1844,10 @@ func databaseClusterWithShardsUpdateProcessError(err error, clusterID string, sh
 		newErrMsg = fmt.Sprintf("unable to determine wal_disk_autoexpand from vkcs_db_cluster_with_shards %s", clusterID)
 	case errDBClusterUpdateCloudMonitoring:
 		newErrMsg = fmt.Sprintf("error updating cloud_monitoring_enabled for vkcs_db_cluster_with_shards %s", clusterID)
+	case errDBClusterUpdateBackupSchedule:
+		newErrMsg = fmt.Sprintf("error updating backup_schedule for vkcs_db_cluster_with_shards %s", clusterID)
+	case errDBClusterUpdateBackupScheduleExtract:
+		newErrMsg = fmt.Sprintf("unable to determine backup_schedule from vkcs_db_cluster_with_shards %s", clusterID)
 
 	case errDBClusterActionUpdateConfiguration:
 		newErrMsg = fmt.Sprintf("error updating configuration for vkcs_db_cluster_with_shards %s", clusterID)
@@ -903,3 +1876,10 @@ func databaseClusterWithShardsUpdateProcessError(err error, clusterID string, sh
 	errMsg := strings.Replace(err.Error(), baseErr.Error(), newErrMsg, 1)
 	return diag.Errorf(errMsg)
 }
+
+func databaseClusterWithShardsEnableRoot(updateCtx *dbResourceUpdateContext) diag.Diagnostics {
+	if updateCtx.D.Get("store_root_password").(bool) {
+		return databaseClusterActionEnableRoot(updateCtx)
+	}
+	return databaseClusterActionEnableRootWithoutStoring(updateCtx)
+}
@@ -3,12 +3,15 @@ package db_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/acctest"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/clients"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/clusters"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/instances"
 )
 
 func TestAccDatabaseClusterWithShards_basic_big(t *testing.T) {
@@ -24,6 +27,11 @@ func TestAccDatabaseClusterWithShards_basic_big(t *testing.T) {
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckDatabaseClusterExists("vkcs_db_cluster_with_shards.basic", &cluster),
 					resource.TestCheckResourceAttrPtr("vkcs_db_cluster_with_shards.basic", "name", &cluster.Name),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.basic", "shard_ids.#", "1"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.basic", "shard_ids.0", "shard0"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.basic", "shard.0.version", "20.8"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.basic", "floating_ip_active", "false"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.basic", "backups.#", "0"),
 				),
 			},
 		},
@@ -53,6 +61,9 @@ func TestAccDatabaseClusterWithShards_update_big(t *testing.T) {
 						"vkcs_networking_network.base", "id"),
 					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.update", "shard.0.volume_size", "8"),
 					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.update", "shard.0.volume_type", "ceph-ssd"),
+					resource.TestCheckResourceAttrPair("vkcs_db_cluster_with_shards.update", "configuration_id",
+						"vkcs_db_config_group.basic", "id"),
+					testAccCheckDatabaseClusterWithShardsConfigurationAttached(&cluster, "vkcs_db_config_group.basic"),
 				),
 			},
 			{
@@ -67,6 +78,152 @@ func TestAccDatabaseClusterWithShards_update_big(t *testing.T) {
 						"data.vkcs_compute_flavor.new_flavor", "id"),
 					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.update", "shard.0.volume_size", "10"),
 					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.update", "shard.0.volume_type", "ceph-hdd"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.update", "backup_schedule.0.name", "three_hours_backup"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.update", "backup_schedule.0.interval_hours", "3"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.update", "backup_schedule.0.keep_count", "3"),
+					testAccCheckDatabaseClusterExists("vkcs_db_cluster_with_shards.update", &cluster),
+					testAccCheckDatabaseClusterWithShardsInstancesAutoexpand(&cluster, 1000),
+				),
+			},
+			{
+				Config: acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsUpdateMonitoringDisabled),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.update", "cloud_monitoring_enabled", "false"),
+					testAccCheckDatabaseClusterWithShardsNotRecreated("vkcs_db_cluster_with_shards.update", &cluster),
+				),
+			},
+			{
+				Config:   acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsUpdateMonitoringDisabled),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccDatabaseClusterWithShards_configurationName_big(t *testing.T) {
+	var cluster clusters.ClusterResp
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		CheckDestroy:      testAccCheckDatabaseClusterWithShardsDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsConfigurationName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckDatabaseClusterExists("vkcs_db_cluster_with_shards.configuration_name", &cluster),
+					resource.TestCheckResourceAttrPair("vkcs_db_cluster_with_shards.configuration_name", "configuration_name",
+						"vkcs_db_config_group.configuration_name", "name"),
+					testAccCheckDatabaseClusterWithShardsConfigurationAttached(&cluster, "vkcs_db_config_group.configuration_name"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDatabaseClusterWithShards_externalResize_big(t *testing.T) {
+	var cluster clusters.ClusterResp
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		CheckDestroy:      testAccCheckDatabaseClusterWithShardsDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsExternalResize),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckDatabaseClusterExists("vkcs_db_cluster_with_shards.external_resize", &cluster),
+					testAccDatabaseClusterWithShardsResizeLastInstance(&cluster, "data.vkcs_compute_flavor.new_flavor"),
+				),
+			},
+			{
+				Config:             acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsExternalResize),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccDatabaseClusterWithShards_externalConfiguration_big(t *testing.T) {
+	var cluster clusters.ClusterResp
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		CheckDestroy:      testAccCheckDatabaseClusterWithShardsDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsConfigurationName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckDatabaseClusterExists("vkcs_db_cluster_with_shards.configuration_name", &cluster),
+					testAccDatabaseClusterWithShardsDetachConfiguration(&cluster),
+				),
+			},
+			{
+				Config:             acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsConfigurationName),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsConfigurationName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckDatabaseClusterExists("vkcs_db_cluster_with_shards.configuration_name", &cluster),
+					testAccCheckDatabaseClusterWithShardsConfigurationAttached(&cluster, "vkcs_db_config_group.configuration_name"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDatabaseClusterWithShards_flavorName_big(t *testing.T) {
+	var cluster clusters.ClusterResp
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		CheckDestroy:      testAccCheckDatabaseClusterWithShardsDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsFlavorName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckDatabaseClusterExists("vkcs_db_cluster_with_shards.flavor_name", &cluster),
+					resource.TestCheckResourceAttrPair("vkcs_db_cluster_with_shards.flavor_name", "shard.0.flavor_name",
+						"data.vkcs_compute_flavor.base", "name"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.flavor_name", "shard.0.flavor_id", ""),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.flavor_name", "shard.1.flavor_id", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDatabaseClusterWithShards_defaultFlavor_big(t *testing.T) {
+	var cluster clusters.ClusterResp
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { acctest.AccTestPreCheck(t) },
+		ProviderFactories: acctest.AccTestProviders,
+		CheckDestroy:      testAccCheckDatabaseClusterWithShardsDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsDefaultFlavor, map[string]string{"FlavorID": "data.vkcs_compute_flavor.base.id"}),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckDatabaseClusterExists("vkcs_db_cluster_with_shards.default_flavor", &cluster),
+					resource.TestCheckResourceAttrPair("vkcs_db_cluster_with_shards.default_flavor", "flavor_id",
+						"data.vkcs_compute_flavor.base", "id"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.default_flavor", "shard.0.flavor_id", ""),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.default_flavor", "shard.1.flavor_id", ""),
+				),
+			},
+			{
+				Config: acctest.AccTestRenderConfig(testAccDatabaseClusterWithShardsDefaultFlavor, map[string]string{"FlavorID": "data.vkcs_compute_flavor.new_flavor.id"}),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckDatabaseClusterWithShardsNotRecreated("vkcs_db_cluster_with_shards.default_flavor", &cluster),
+					resource.TestCheckResourceAttrPair("vkcs_db_cluster_with_shards.default_flavor", "flavor_id",
+						"data.vkcs_compute_flavor.new_flavor", "id"),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.default_flavor", "shard.0.flavor_id", ""),
+					resource.TestCheckResourceAttr("vkcs_db_cluster_with_shards.default_flavor", "shard.1.flavor_id", ""),
 				),
 			},
 		},
@@ -142,6 +299,136 @@ func TestAccDatabaseClusterWithShards_resize_big(t *testing.T) {
 	})
 }
 
+func testAccDatabaseClusterWithShardsResizeLastInstance(cluster *clusters.ClusterResp, flavorName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[flavorName]
+		if !ok {
+			return fmt.Errorf("not found: %s", flavorName)
+		}
+
+		config := acctest.AccTestProvider.Meta().(clients.Config)
+		DatabaseClient, err := config.DatabaseV1Client(acctest.OsRegionName)
+		if err != nil {
+			return fmt.Errorf("Error creating VKCS database client: %s", err)
+		}
+
+		inst := cluster.Instances[len(cluster.Instances)-1]
+		var resizeOpts instances.ResizeOpts
+		resizeOpts.Resize.FlavorRef = rs.Primary.ID
+		err = instances.Action(DatabaseClient, inst.ID, &resizeOpts).ExtractErr()
+		if err != nil {
+			return fmt.Errorf("error resizing instance %s: %s", inst.ID, err)
+		}
+
+		stateConf := &retry.StateChangeConf{
+			Pending: []string{"RESIZE"},
+			Target:  []string{"ACTIVE"},
+			Refresh: func() (interface{}, string, error) {
+				i, err := instances.Get(DatabaseClient, inst.ID).Extract()
+				if err != nil {
+					return nil, "", err
+				}
+				if i.Flavor == nil || i.Flavor.ID != rs.Primary.ID {
+					return i, "RESIZE", nil
+				}
+				return i, i.Status, nil
+			},
+			Timeout:    30 * time.Minute,
+			Delay:      10 * time.Second,
+			MinTimeout: 10 * time.Second,
+		}
+		_, err = stateConf.WaitForState()
+		return err
+	}
+}
+
+func testAccDatabaseClusterWithShardsDetachConfiguration(cluster *clusters.ClusterResp) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config := acctest.AccTestProvider.Meta().(clients.Config)
+		DatabaseClient, err := config.DatabaseV1Client(acctest.OsRegionName)
+		if err != nil {
+			return fmt.Errorf("Error creating VKCS database client: %s", err)
+		}
+
+		var detachOpts clusters.DetachConfigurationGroupOpts
+		detachOpts.ConfigurationDetach.ConfigurationID = cluster.ConfigurationID
+		err = clusters.ClusterAction(DatabaseClient, cluster.ID, &detachOpts).ExtractErr()
+		if err != nil {
+			return fmt.Errorf("error detaching configuration from cluster %s: %s", cluster.ID, err)
+		}
+
+		stateConf := &retry.StateChangeConf{
+			Pending: []string{"ATTACHED"},
+			Target:  []string{"DETACHED"},
+			Refresh: func() (interface{}, string, error) {
+				c, err := clusters.Get(DatabaseClient, cluster.ID).Extract()
+				if err != nil {
+					return nil, "", err
+				}
+				if c.ConfigurationID != "" {
+					return c, "ATTACHED", nil
+				}
+				return c, "DETACHED", nil
+			},
+			Timeout:    30 * time.Minute,
+			Delay:      10 * time.Second,
+			MinTimeout: 10 * time.Second,
+		}
+		_, err = stateConf.WaitForState()
+		return err
+	}
+}
+
+func testAccCheckDatabaseClusterWithShardsConfigurationAttached(cluster *clusters.ClusterResp, configGroupName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[configGroupName]
+		if !ok {
+			return fmt.Errorf("not found: %s", configGroupName)
+		}
+		if cluster.ConfigurationID != rs.Primary.ID {
+			return fmt.Errorf("expected configuration %s to be attached, got %s", rs.Primary.ID, cluster.ConfigurationID)
+		}
+		return nil
+	}
+}
+
+func testAccCheckDatabaseClusterWithShardsInstancesAutoexpand(cluster *clusters.ClusterResp, maxDiskSize int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config := acctest.AccTestProvider.Meta().(clients.Config)
+		DatabaseClient, err := config.DatabaseV1Client(acctest.OsRegionName)
+		if err != nil {
+			return fmt.Errorf("Error creating VKCS database client: %s", err)
+		}
+
+		for _, clusterInst := range cluster.Instances {
+			inst, err := instances.Get(DatabaseClient, clusterInst.ID).Extract()
+			if err != nil {
+				return err
+			}
+			if inst.AutoExpand != 1 || inst.MaxDiskSize != maxDiskSize {
+				return fmt.Errorf("disk autoexpand is not applied to instance %s of shard %s", inst.ID, clusterInst.ShardID)
+			}
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckDatabaseClusterWithShardsNotRecreated(n string, cluster *clusters.ClusterResp) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("not found: %s", n)
+		}
+
+		if rs.Primary.ID != cluster.ID {
+			return fmt.Errorf("cluster was recreated: id changed from %s to %s", cluster.ID, rs.Primary.ID)
+		}
+
+		return nil
+	}
+}
+
 func testAccCheckDatabaseClusterWithShardsDestroy(s *terraform.State) error {
 	config := acctest.AccTestProvider.Meta().(clients.Config)
 
@@ -268,6 +555,77 @@ resource "vkcs_db_cluster_with_shards" "update" {
 
   cloud_monitoring_enabled = true
 
+  backup_schedule {
+    name           = "three_hours_backup"
+    start_hours    = 16
+    start_minutes  = 20
+    interval_hours = 3
+    keep_count     = 3
+  }
+
+  shard {
+    size        = 1
+    shard_id    = "shard0"
+    flavor_id   = data.vkcs_compute_flavor.new_flavor.id
+    volume_size = 10
+    volume_type = "ceph-hdd"
+    network {
+      uuid = vkcs_networking_network.base.id
+    }
+    availability_zone = "{{.AvailabilityZone}}"
+  }
+
+  vendor_options {
+	restart_confirmed = true
+  }
+
+  depends_on = [vkcs_networking_router_interface.base]
+}
+`
+
+const testAccDatabaseClusterWithShardsUpdateMonitoringDisabled = `
+{{.BaseNetwork}}
+{{.BaseFlavor}}
+
+data "vkcs_compute_flavor" "new_flavor" {
+  name = "Standard-4-8-80"
+}
+
+resource "vkcs_db_config_group" "basic" {
+  name = "basic"
+  datastore {
+    version = "20.8"
+    type    = "clickhouse"
+  }
+  values = {
+    "yandex.max_connections": "2048"
+  }
+}
+
+resource "vkcs_db_cluster_with_shards" "update" {
+  name = "update"
+
+  datastore {
+    version = "20.8"
+    type    = "clickhouse"
+  }
+  configuration_id = vkcs_db_config_group.basic.id
+
+  disk_autoexpand {
+    autoexpand    = true
+    max_disk_size = 1000
+  }
+
+  cloud_monitoring_enabled = false
+
+  backup_schedule {
+    name           = "three_hours_backup"
+    start_hours    = 16
+    start_minutes  = 20
+    interval_hours = 3
+    keep_count     = 3
+  }
+
   shard {
     size        = 1
     shard_id    = "shard0"
@@ -387,3 +745,158 @@ resource "vkcs_db_cluster_with_shards" "resize" {
   depends_on = [vkcs_networking_router_interface.base]
 }
 `
+
+const testAccDatabaseClusterWithShardsConfigurationName = `
+{{.BaseNetwork}}
+{{.BaseFlavor}}
+
+resource "vkcs_db_config_group" "configuration_name" {
+  name = "configuration-name"
+  datastore {
+    version = "20.8"
+    type    = "clickhouse"
+  }
+  values = {
+    "yandex.max_connections": "1024"
+  }
+}
+
+resource "vkcs_db_cluster_with_shards" "configuration_name" {
+  name = "configuration-name"
+
+  datastore {
+    version = "20.8"
+    type    = "clickhouse"
+  }
+  configuration_name = vkcs_db_config_group.configuration_name.name
+
+  shard {
+    size        = 1
+    shard_id    = "shard0"
+    flavor_id   = data.vkcs_compute_flavor.base.id
+    volume_size = 8
+    volume_type = "ceph-ssd"
+    network {
+      uuid = vkcs_networking_network.base.id
+    }
+    availability_zone = "{{.AvailabilityZone}}"
+  }
+
+  depends_on = [vkcs_networking_router_interface.base]
+}
+`
+
+const testAccDatabaseClusterWithShardsExternalResize = `
+{{.BaseNetwork}}
+{{.BaseFlavor}}
+
+data "vkcs_compute_flavor" "new_flavor" {
+  name = "Standard-4-8-80"
+}
+
+resource "vkcs_db_cluster_with_shards" "external_resize" {
+  name = "external-resize"
+
+  datastore {
+    version = "20.8"
+    type    = "clickhouse"
+  }
+
+  shard {
+    size        = 2
+    shard_id    = "shard0"
+    flavor_id   = data.vkcs_compute_flavor.base.id
+    volume_size = 8
+    volume_type = "ceph-ssd"
+    network {
+      uuid = vkcs_networking_network.base.id
+    }
+    availability_zone = "{{.AvailabilityZone}}"
+  }
+
+  depends_on = [vkcs_networking_router_interface.base]
+}
+`
+
+const testAccDatabaseClusterWithShardsFlavorName = `
+{{.BaseNetwork}}
+{{.BaseFlavor}}
+
+resource "vkcs_db_cluster_with_shards" "flavor_name" {
+  name = "flavor-name"
+
+  datastore {
+    version = "20.8"
+    type    = "clickhouse"
+  }
+
+  shard {
+    size        = 1
+    shard_id    = "shard0"
+    flavor_name = data.vkcs_compute_flavor.base.name
+    volume_size = 8
+    volume_type = "ceph-ssd"
+    network {
+      uuid = vkcs_networking_network.base.id
+    }
+    availability_zone = "{{.AvailabilityZone}}"
+  }
+
+  shard {
+    size        = 1
+    shard_id    = "shard1"
+    flavor_name = data.vkcs_compute_flavor.base.name
+    volume_size = 8
+    volume_type = "ceph-ssd"
+    network {
+      uuid = vkcs_networking_network.base.id
+    }
+    availability_zone = "{{.AvailabilityZone}}"
+  }
+
+  depends_on = [vkcs_networking_router_interface.base]
+}
+`
+
+const testAccDatabaseClusterWithShardsDefaultFlavor = `
+{{.BaseNetwork}}
+{{.BaseFlavor}}
+
+data "vkcs_compute_flavor" "new_flavor" {
+  name = "Standard-4-8-80"
+}
+
+resource "vkcs_db_cluster_with_shards" "default_flavor" {
+  name      = "default-flavor"
+  flavor_id = {{.FlavorID}}
+
+  datastore {
+    version = "20.8"
+    type    = "clickhouse"
+  }
+
+  shard {
+    size        = 1
+    shard_id    = "shard0"
+    volume_size = 8
+    volume_type = "ceph-ssd"
+    network {
+      uuid = vkcs_networking_network.base.id
+    }
+    availability_zone = "{{.AvailabilityZone}}"
+  }
+
+  shard {
+    size        = 1
+    shard_id    = "shard1"
+    volume_size = 8
+    volume_type = "ceph-ssd"
+    network {
+      uuid = vkcs_networking_network.base.id
+    }
+    availability_zone = "{{.AvailabilityZone}}"
+  }
+
+  depends_on = [vkcs_networking_router_interface.base]
+}
+`
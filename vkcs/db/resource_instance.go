@@ -68,7 +68,11 @@ func ResourceDatabaseInstance() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
 				config := meta.(clients.Config)
-				DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+				region, err := util.RequireRegion(d, config)
+				if err != nil {
+					return nil, err
+				}
+				DatabaseV1Client, err := config.DatabaseV1Client(region)
 				if err != nil {
 					return nil, fmt.Errorf("error creating VKCS database client: %s", err)
 				}
@@ -478,7 +482,11 @@ func ResourceDatabaseInstance() *schema.Resource {
 
 func resourceDatabaseInstanceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
@@ -680,7 +688,11 @@ func resourceDatabaseInstanceCreate(ctx context.Context, d *schema.ResourceData,
 
 func resourceDatabaseInstanceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
@@ -698,7 +710,7 @@ func resourceDatabaseInstanceRead(ctx context.Context, d *schema.ResourceData, m
 	if _, ok := d.GetOk("disk_autoexpand"); ok {
 		d.Set("disk_autoexpand", flattenDatabaseInstanceAutoExpand(instance.AutoExpand, instance.MaxDiskSize))
 	}
-	d.Set("region", util.GetRegion(d, config))
+	d.Set("region", region)
 	d.Set("size", instance.Volume.Size)
 	d.Set("configuration_id", instance.ConfigurationID)
 	if instance.WalVolume != nil && instance.WalVolume.VolumeID != "" {
@@ -767,7 +779,11 @@ func resourceDatabaseInstanceRead(ctx context.Context, d *schema.ResourceData, m
 
 func resourceDatabaseInstanceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
@@ -1064,7 +1080,11 @@ func resourceDatabaseInstanceUpdate(ctx context.Context, d *schema.ResourceData,
 
 func resourceDatabaseInstanceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	config := meta.(clients.Config)
-	DatabaseV1Client, err := config.DatabaseV1Client(util.GetRegion(d, config))
+	region, err := util.RequireRegion(d, config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	DatabaseV1Client, err := config.DatabaseV1Client(region)
 	if err != nil {
 		return diag.Errorf("Error creating VKCS database client: %s", err)
 	}
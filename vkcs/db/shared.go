@@ -3,10 +3,19 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gophercloud/gophercloud"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/backups"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/datastores"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/instances"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
 )
 
@@ -64,3 +73,116 @@ func checkDBNetworks(
 	}
 	return diags
 }
+
+// getDatabaseDatastoreCapabilities returns capabilities available for the
+// datastore version with the given name.
+func getDatabaseDatastoreCapabilities(client *gophercloud.ServiceClient, dsType, dsVersion string) ([]datastores.Capability, error) {
+	ds, err := datastores.Get(client, dsType).Extract()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving datastore %s: %s", dsType, err)
+	}
+	for _, v := range ds.Versions {
+		if v.Name == dsVersion {
+			return datastores.ListCapabilities(client, dsType, v.ID).Extract()
+		}
+	}
+	return nil, fmt.Errorf("datastore %s has no version %s", dsType, dsVersion)
+}
+
+// validateDatabaseOperationTimeout checks that the value is a positive
+// duration, e.g. "90m".
+func validateDatabaseOperationTimeout(v interface{}, k string) ([]string, []error) {
+	value, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s %q is not a valid duration: %s", k, value, err)}
+	}
+	if timeout <= 0 {
+		return nil, []error{fmt.Errorf("%s must be positive, got %q", k, value)}
+	}
+	return nil, nil
+}
+
+// validateDatabaseBackupDatastore checks that the backup was made of the
+// datastore the resource is going to be restored with.
+func validateDatabaseBackupDatastore(backup *backups.BackupResp, dsType, dsVersion string) error {
+	if backup.Datastore == nil {
+		return nil
+	}
+	if !strings.EqualFold(backup.Datastore.Type, dsType) || backup.Datastore.Version != dsVersion {
+		return fmt.Errorf("backup %s was made of datastore %s %s, it can't be restored with datastore %s %s",
+			backup.ID, backup.Datastore.Type, backup.Datastore.Version, dsType, dsVersion)
+	}
+	return nil
+}
+
+// validateDatabaseCapabilities checks settings of the capabilities against
+// parameters of the capabilities available for the datastore.
+func validateDatabaseCapabilities(capabilities []instances.CapabilityOpts, available []datastores.Capability) error {
+	availableByName := make(map[string]datastores.Capability, len(available))
+	for _, c := range available {
+		availableByName[c.Name] = c
+	}
+
+	for _, c := range capabilities {
+		dsCapability, ok := availableByName[c.Name]
+		if !ok {
+			return fmt.Errorf("capability %s is not available for the datastore", c.Name)
+		}
+		for name, value := range c.Params {
+			param, ok := dsCapability.Params[name]
+			if !ok || param == nil {
+				return fmt.Errorf("capability %s has no setting %s", c.Name, name)
+			}
+			if err := validateDatabaseCapabilityParam(param, value); err != nil {
+				return fmt.Errorf("invalid value %q of setting %s of capability %s: %s", value, name, c.Name, err)
+			}
+		}
+		for name, param := range dsCapability.Params {
+			if _, ok := c.Params[name]; param != nil && param.Required && param.DefaultValue == nil && !ok {
+				return fmt.Errorf("setting %s of capability %s is required", name, c.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func validateDatabaseCapabilityParam(param *datastores.CapabilityParam, value string) error {
+	if len(param.EnumValues) > 0 {
+		for _, v := range param.EnumValues {
+			if v == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected one of %v", param.EnumValues)
+	}
+
+	switch param.Type {
+	case "integer", "float":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("expected %s", param.Type)
+		}
+		if param.Type == "integer" && number != float64(int64(number)) {
+			return fmt.Errorf("expected integer")
+		}
+		if param.MinValue < param.MaxValue && (number < param.MinValue || number > param.MaxValue) {
+			return fmt.Errorf("expected value in range [%v, %v]", param.MinValue, param.MaxValue)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("expected boolean")
+		}
+	}
+
+	if param.Regex != "" {
+		re, err := regexp.Compile(param.Regex)
+		if err == nil && !re.MatchString(value) {
+			return fmt.Errorf("expected value matching %s", param.Regex)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/backups"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/datastores"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/instances"
+	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
+)
+
+func TestValidateDatabaseCapabilities(t *testing.T) {
+	available := []datastores.Capability{
+		{
+			Name: "capability0",
+			Params: map[string]*datastores.CapabilityParam{
+				"count":   {Type: "integer", MinValue: 1, MaxValue: 10},
+				"enabled": {Type: "boolean"},
+				"mode":    {Type: "string", EnumValues: []string{"sync", "async"}},
+				"host":    {Type: "string", Required: true},
+			},
+		},
+	}
+
+	valid := []instances.CapabilityOpts{
+		{Name: "capability0", Params: map[string]string{"count": "5", "enabled": "true", "mode": "sync", "host": "h"}},
+	}
+	assert.NoError(t, validateDatabaseCapabilities(valid, available))
+
+	cases := map[string]instances.CapabilityOpts{
+		"capability capability1 is not available for the datastore": {
+			Name: "capability1",
+		},
+		"capability capability0 has no setting unknown": {
+			Name: "capability0", Params: map[string]string{"host": "h", "unknown": "1"},
+		},
+		`invalid value "11" of setting count of capability capability0: expected value in range [1, 10]`: {
+			Name: "capability0", Params: map[string]string{"host": "h", "count": "11"},
+		},
+		`invalid value "1.5" of setting count of capability capability0: expected integer`: {
+			Name: "capability0", Params: map[string]string{"host": "h", "count": "1.5"},
+		},
+		`invalid value "yes" of setting enabled of capability capability0: expected boolean`: {
+			Name: "capability0", Params: map[string]string{"host": "h", "enabled": "yes"},
+		},
+		`invalid value "none" of setting mode of capability capability0: expected one of [sync async]`: {
+			Name: "capability0", Params: map[string]string{"host": "h", "mode": "none"},
+		},
+		"setting host of capability capability0 is required": {
+			Name: "capability0",
+		},
+	}
+	for expected, c := range cases {
+		assert.EqualError(t, validateDatabaseCapabilities([]instances.CapabilityOpts{c}, available), expected)
+	}
+}
+
+func TestValidateDatabaseBackupDatastore(t *testing.T) {
+	backup := &backups.BackupResp{
+		ID:        "backup1",
+		Datastore: &datastores.DatastoreShort{Type: "clickhouse", Version: "20.8"},
+	}
+
+	assert.NoError(t, validateDatabaseBackupDatastore(backup, "ClickHouse", "20.8"))
+	assert.Error(t, validateDatabaseBackupDatastore(backup, "clickhouse", "23.3"))
+	assert.Error(t, validateDatabaseBackupDatastore(backup, "mongodb", "20.8"))
+	assert.NoError(t, validateDatabaseBackupDatastore(&backups.BackupResp{ID: "backup2"}, "clickhouse", "20.8"))
+}
+
+type testRegionGetter string
+
+func (r testRegionGetter) GetRegion() string {
+	return string(r)
+}
+
+func TestDatabaseResourcesRegion(t *testing.T) {
+	for name, r := range map[string]*schema.Resource{
+		"vkcs_db_cluster":             ResourceDatabaseCluster(),
+		"vkcs_db_cluster_with_shards": ResourceDatabaseClusterWithShards(),
+		"vkcs_db_instance":            ResourceDatabaseInstance(),
+	} {
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"region": "region-resource"})
+		assert.Equal(t, "region-resource", util.GetRegion(d, testRegionGetter("region-provider")), name)
+
+		d = schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{})
+		assert.Equal(t, "region-provider", util.GetRegion(d, testRegionGetter("region-provider")), name)
+		_, err := util.RequireRegion(d, testRegionGetter(""))
+		assert.ErrorIs(t, err, util.ErrRegionNotSet, name)
+	}
+}
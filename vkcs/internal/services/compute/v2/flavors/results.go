@@ -9,6 +9,14 @@ type FlavorExtExtraSpecs struct {
 	ExtraSpecs map[string]interface{} `json:"extra_specs"`
 }
 
+type FlavorExtDescription struct {
+	Description string `json:"description"`
+}
+
 func ExtractFlavorsInto(r pagination.Page, to interface{}) error {
 	return (r.(flavors.FlavorPage)).Result.ExtractIntoSlicePtr(to, "flavors")
 }
+
+func ExtractFlavorInto(r flavors.GetResult, to interface{}) error {
+	return r.ExtractIntoStructPtr(to, "flavor")
+}
@@ -2,6 +2,7 @@ package backups
 
 import (
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
 )
 
@@ -59,3 +60,37 @@ func Delete(client *gophercloud.ServiceClient, id string) (r DeleteResult) {
 	r.Err = util.ErrorWithRequestID(r.Err, r.Header.Get(util.RequestIDHeader))
 	return
 }
+
+// ListOptsBuilder allows extensions to add additional parameters to the
+// list request.
+type ListOptsBuilder interface {
+	ToBackupListQuery() (string, error)
+}
+
+// ListOpts allows filtering of database backups.
+type ListOpts struct {
+	// ClusterID filters backups of the cluster.
+	ClusterID string `q:"cluster_id"`
+}
+
+// ToBackupListQuery formats a ListOpts structure into a query string.
+func (opts ListOpts) ToBackupListQuery() (string, error) {
+	q, err := gophercloud.BuildQueryString(opts)
+	return q.String(), err
+}
+
+// List will list database backups
+func List(client *gophercloud.ServiceClient, opts ListOptsBuilder) pagination.Pager {
+	url := backupsURL(client, "")
+	if opts != nil {
+		query, err := opts.ToBackupListQuery()
+		if err != nil {
+			return pagination.Pager{Err: err}
+		}
+		url += query
+	}
+	return pagination.NewPager(client, url,
+		func(r pagination.PageResult) pagination.Page {
+			return Page{pagination.SinglePageBase(r)}
+		})
+}
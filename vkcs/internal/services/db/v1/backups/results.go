@@ -2,6 +2,7 @@ package backups
 
 import (
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/datastores"
 )
 
@@ -44,3 +45,24 @@ func (r GetResult) Extract() (*BackupResp, error) {
 	}
 	return b.Backup, nil
 }
+
+// Page represents a page of database backups
+type Page struct {
+	pagination.SinglePageBase
+}
+
+// IsEmpty indicates whether a database backup collection is empty.
+func (r Page) IsEmpty() (bool, error) {
+	is, err := ExtractBackups(r)
+	return len(is) == 0, err
+}
+
+// ExtractBackups retrieves a slice of database backupResp structs from a paginated
+// collection.
+func ExtractBackups(r pagination.Page) ([]BackupResp, error) {
+	var s struct {
+		Backups []BackupResp `json:"backups"`
+	}
+	err := (r.(Page)).ExtractInto(&s)
+	return s.Backups, err
+}
@@ -2,6 +2,7 @@ package configgroups
 
 import (
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/pagination"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/services/db/v1/datastores"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util"
 )
@@ -64,6 +65,14 @@ func Get(client *gophercloud.ServiceClient, id string) (r GetResult) {
 	return
 }
 
+// List will list all configuration groups available to the project.
+func List(client *gophercloud.ServiceClient) pagination.Pager {
+	return pagination.NewPager(client, configGroupsURL(client),
+		func(r pagination.PageResult) pagination.Page {
+			return Page{pagination.SinglePageBase(r)}
+		})
+}
+
 func Update(client *gophercloud.ServiceClient, id string, opts OptsBuilder) (r UpdateResult) {
 	b, err := opts.Map()
 	if err != nil {
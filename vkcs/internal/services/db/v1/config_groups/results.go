@@ -2,6 +2,7 @@ package configgroups
 
 import (
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/pagination"
 )
 
 type ConfigGroupResp struct {
@@ -46,3 +47,23 @@ func (r commonConfigGroupResult) Extract() (*ConfigGroupResp, error) {
 	}
 	return c.Configuration, nil
 }
+
+// Page represents a page of configuration group resources.
+type Page struct {
+	pagination.SinglePageBase
+}
+
+func (r Page) IsEmpty() (bool, error) {
+	cgs, err := ExtractConfigGroups(r)
+	return len(cgs) == 0, err
+}
+
+// ExtractConfigGroups retrieves a slice of configuration group structs from
+// a paginated collection.
+func ExtractConfigGroups(r pagination.Page) ([]ConfigGroupResp, error) {
+	var s struct {
+		Configurations []ConfigGroupResp `json:"configurations"`
+	}
+	err := (r.(Page)).ExtractInto(&s)
+	return s.Configurations, err
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -15,7 +16,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/mitchellh/mapstructure"
-	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/clients"
 	"github.com/vk-cs/terraform-provider-vkcs/vkcs/internal/util/errutil"
 )
 
@@ -123,10 +123,23 @@ func CheckAlreadyExists(err error, msg, resourceName, conflict string) error {
 	return fmt.Errorf("%s: %s", msg, err)
 }
 
+// RegionGetter provides the provider-level region. It is implemented by
+// clients.Config and can be faked in tests.
+type RegionGetter interface {
+	GetRegion() string
+}
+
+// RegionResource provides the resource-level region. It is implemented by
+// both schema.ResourceData and schema.ResourceDiff.
+type RegionResource interface {
+	GetOk(string) (interface{}, bool)
+}
+
 // GetRegion returns the region that was specified in the resource. If a
 // region was not set, the provider-level region is checked. The provider-level
 // region can either be set by the region argument or by OS_REGION_NAME.
-func GetRegion(d *schema.ResourceData, config clients.Config) string {
+// An empty string is returned if neither is set, see RequireRegion.
+func GetRegion(d RegionResource, config RegionGetter) string {
 	if v, ok := d.GetOk("region"); ok {
 		return v.(string)
 	}
@@ -134,6 +147,20 @@ func GetRegion(d *schema.ResourceData, config clients.Config) string {
 	return config.GetRegion()
 }
 
+// ErrRegionNotSet is returned by RequireRegion when neither the resource nor
+// the provider has a region.
+var ErrRegionNotSet = errors.New("region is not set, please set region of the resource or the provider, or OS_REGION_NAME")
+
+// RequireRegion returns the region like GetRegion, but fails with
+// ErrRegionNotSet instead of returning an empty string.
+func RequireRegion(d RegionResource, config RegionGetter) (string, error) {
+	region := GetRegion(d, config)
+	if region == "" {
+		return "", ErrRegionNotSet
+	}
+	return region, nil
+}
+
 // AddValueSpecs expands the 'value_specs' object and removes 'value_specs'
 // from the reqeust body.
 func AddValueSpecs(body map[string]interface{}) map[string]interface{} {
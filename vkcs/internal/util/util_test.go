@@ -0,0 +1,39 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRegionGetter string
+
+func (r testRegionGetter) GetRegion() string {
+	return string(r)
+}
+
+func TestGetRegion(t *testing.T) {
+	regionSchema := map[string]*schema.Schema{
+		"region": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Computed: true,
+		},
+	}
+
+	d := schema.TestResourceDataRaw(t, regionSchema, map[string]interface{}{"region": "region-resource"})
+	assert.Equal(t, "region-resource", GetRegion(d, testRegionGetter("region-provider")))
+
+	d = schema.TestResourceDataRaw(t, regionSchema, map[string]interface{}{})
+	assert.Equal(t, "region-provider", GetRegion(d, testRegionGetter("region-provider")))
+
+	d = schema.TestResourceDataRaw(t, regionSchema, map[string]interface{}{})
+	assert.Empty(t, GetRegion(d, testRegionGetter("")))
+
+	region, err := RequireRegion(d, testRegionGetter("region-provider"))
+	assert.NoError(t, err)
+	assert.Equal(t, "region-provider", region)
+	_, err = RequireRegion(d, testRegionGetter(""))
+	assert.ErrorIs(t, err, ErrRegionNotSet)
+}
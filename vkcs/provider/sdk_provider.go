@@ -85,6 +85,8 @@ func SDKProviderBase() *sdkschema.Provider {
 			"vkcs_compute_instance":              compute.DataSourceComputeInstance(),
 			"vkcs_compute_availability_zones":    compute.DataSourceComputeAvailabilityZones(),
 			"vkcs_compute_flavor":                compute.DataSourceComputeFlavor(),
+			"vkcs_compute_flavor_extra_specs":    compute.DataSourceComputeFlavorExtraSpecs(),
+			"vkcs_compute_flavors":               compute.DataSourceComputeFlavors(),
 			"vkcs_compute_quotaset":              compute.DataSourceComputeQuotaset(),
 			"vkcs_images_image":                  images.DataSourceImagesImage(),
 			"vkcs_networking_network":            networking.DataSourceNetworkingNetwork(),
@@ -96,6 +98,7 @@ func SDKProviderBase() *sdkschema.Provider {
 			"vkcs_lb_loadbalancer":               lb.DataSourceLoadBalancer(),
 			"vkcs_sharedfilesystem_sharenetwork": sharedfilesystem.DataSourceSharedFilesystemShareNetwork(),
 			"vkcs_sharedfilesystem_share":        sharedfilesystem.DataSourceSharedFilesystemShare(),
+			"vkcs_db_cluster_capabilities":       db.DataSourceDatabaseClusterCapabilities(),
 			"vkcs_db_database":                   db.DataSourceDatabaseDatabase(),
 			"vkcs_db_instance":                   db.DataSourceDatabaseInstance(),
 			"vkcs_db_user":                       db.DataSourceDatabaseUser(),